@@ -0,0 +1,62 @@
+package config
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// AccessFunc is called by OnAccess for every property read: key is the
+// property, method the Config method called (e.g. GetString), found whether
+// the property is set and caller the code that called the method
+type AccessFunc func(key string, method string, found bool, caller runtime.Frame)
+
+var pkgPrefix = reflect.TypeOf(Config{}).PkgPath() + "."
+
+// Call fn for every property read, to trace which code reads which keys.
+// It slows down every read, so enable it only while debugging; nil disables
+// it. skip moves the reported caller up the stack past helpers wrapping the
+// Config methods, 0 reports the direct caller.
+func (c *Config) OnAccess(fn AccessFunc, skip int) {
+	if c.parent != nil {
+		c.parent.OnAccess(fn, skip)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onAccess = fn
+	c.accessSkip = skip
+}
+
+// traceAccess reports the read of key to the OnAccess function
+func (c *Config) traceAccess(fn AccessFunc, skip int, key string, found bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	// the method is the outermost frame of this package, the caller the
+	// frames after it
+	method := ``
+	var caller runtime.Frame
+	for {
+		f, more := frames.Next()
+		if strings.HasPrefix(f.Function, pkgPrefix) {
+			if name := f.Function[strings.LastIndex(f.Function, ".")+1:]; !strings.HasPrefix(name, "func") {
+				method = name
+			}
+		} else if method != `` {
+			if skip == 0 {
+				caller = f
+				break
+			}
+			skip--
+		}
+		if !more {
+			break
+		}
+	}
+
+	fn(key, method, found, caller)
+}
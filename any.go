@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Read the stored text of property without any conversion
+func (c *Config) GetRaw(name string) (string, bool) {
+	return c.lookupRaw(name)
+}
+
+// Read property converted to its natural type: bool for true/false, int64
+// for integers, float64 for decimal numbers and string otherwise. A kind set
+// with DeclareType is used instead of guessing. Returns nil if property is
+// not exists.
+func (c *Config) GetAny(name string) interface{} {
+	if c.parent != nil {
+		return c.parent.GetAny(c.subPrefix + normalizeKey(name))
+	}
+
+	val, ok := c.lookup(name)
+	if !ok {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, _ := c.typedValue(normalizeKey(name), val)
+	return v
+}
+
+// guessType converts a stored value to bool, int64 or float64 when it is
+// written exactly like one. Zero padded numbers (IDs, octal modes) and
+// exponents stay strings.
+func guessType(val string) interface{} {
+	switch val {
+	case `true`:
+		return true
+	case `false`:
+		return false
+	}
+
+	digits := strings.TrimPrefix(val, `-`)
+	if digits == `` || !isDigit(digits[0]) || strings.ContainsAny(digits, `eE_xXoObB+`) {
+		return val
+	}
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return val
+	}
+
+	if i, e := strconv.ParseInt(val, 10, 64); e == nil {
+		return i
+	}
+	if strings.Count(digits, `.`) == 1 && isDigit(digits[len(digits)-1]) {
+		if f, e := strconv.ParseFloat(val, 64); e == nil {
+			return f
+		}
+	}
+	return val
+}
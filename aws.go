@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials sign requests to AWS. Empty fields are taken from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// awsClient calls an AWS JSON 1.1 API (e.g. SSM, Secrets Manager) signed
+// with Signature Version 4
+type awsClient struct {
+	service  string
+	region   string
+	endpoint string
+	creds    AWSCredentials
+	client   *http.Client
+}
+
+func newAWSClient(service string, region string, endpoint string, creds AWSCredentials, client *http.Client) (*awsClient, error) {
+	if region == `` {
+		region = os.Getenv(`AWS_REGION`)
+	}
+	if region == `` {
+		region = os.Getenv(`AWS_DEFAULT_REGION`)
+	}
+	if region == `` {
+		return nil, errors.New(service + `: no AWS region`)
+	}
+	if creds.AccessKeyID == `` {
+		creds = AWSCredentials{
+			AccessKeyID:     os.Getenv(`AWS_ACCESS_KEY_ID`),
+			SecretAccessKey: os.Getenv(`AWS_SECRET_ACCESS_KEY`),
+			SessionToken:    os.Getenv(`AWS_SESSION_TOKEN`),
+		}
+	}
+	if creds.AccessKeyID == `` || creds.SecretAccessKey == `` {
+		return nil, errors.New(service + `: no AWS credentials`)
+	}
+	if endpoint == `` {
+		endpoint = `https://` + service + `.` + region + `.amazonaws.com`
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &awsClient{
+		service:  service,
+		region:   region,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		creds:    creds,
+		client:   client,
+	}, nil
+}
+
+// call posts req to the target action (e.g. AmazonSSM.GetParametersByPath)
+func (a *awsClient) call(ctx context.Context, target string, req interface{}, resp interface{}) error {
+	body, e := json.Marshal(req)
+	if e != nil {
+		return e
+	}
+
+	hr, e := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if e != nil {
+		return e
+	}
+	hr.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	hr.Header.Set("X-Amz-Target", target)
+
+	r, e := a.do(hr, body)
+	if e != nil {
+		return e
+	}
+	defer r.Body.Close()
+
+	data, e := io.ReadAll(r.Body)
+	if e != nil {
+		return e
+	}
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf(`%s %s: %s: %s`, a.service, target, r.Status, bytes.TrimSpace(data))
+	}
+	return json.Unmarshal(data, resp)
+}
+
+// do signs and sends req, whose body is body
+func (a *awsClient) do(req *http.Request, body []byte) (*http.Response, error) {
+	a.sign(req, body, time.Now().UTC())
+	return a.client.Do(req)
+}
+
+// sign adds the Signature Version 4 headers to req
+func (a *awsClient) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format(`20060102T150405Z`)
+	day := now.Format(`20060102`)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.service == `s3` {
+		req.Header.Set("X-Amz-Content-Sha256", sha256Hex(body))
+	}
+	if a.creds.SessionToken != `` {
+		req.Header.Set("X-Amz-Security-Token", a.creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == `` {
+		path = `/`
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := day + "/" + a.region + "/" + a.service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+a.creds.SecretAccessKey), day)
+	key = hmacSHA256(key, a.region)
+	key = hmacSHA256(key, a.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+a.creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(q url.Values) string {
+	return strings.ReplaceAll(q.Encode(), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
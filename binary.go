@@ -0,0 +1,455 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var errShortData = errors.New(`unexpected end of data`)
+
+// Read MessagePack config file. The top level value must be a map, it is
+// flattened like JSON objects.
+func (f *File) ReadMsgpack(c *Config) error {
+	return f.readBinary(c, func(d *binaryDecoder) (interface{}, error) {
+		return d.msgpack()
+	})
+}
+
+// Read CBOR config file. The top level value must be a map, it is flattened
+// like JSON objects.
+func (f *File) ReadCBOR(c *Config) error {
+	return f.readBinary(c, func(d *binaryDecoder) (interface{}, error) {
+		return d.cbor()
+	})
+}
+
+func (f *File) readBinary(c *Config, decode func(*binaryDecoder) (interface{}, error)) error {
+	data, e := f.readAll(c)
+	if e != nil {
+		return e
+	}
+
+	fmt.Println(`Read config:`, f.filename)
+	c.file = append(c.file, f.filename)
+	if len(data) == 0 {
+		return nil // empty layer
+	}
+
+	v, e := decode(&binaryDecoder{data: data})
+	if e != nil {
+		return fmt.Errorf(`%s: %v`, f.filename, e)
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return fmt.Errorf(`%s: top level value must be a map`, f.filename)
+	}
+
+	for k, val := range Flatten(v) {
+		c.storage[k] = val
+	}
+	return nil
+}
+
+// binaryDecoder decodes MessagePack or CBOR into the same values
+// encoding/json produces, with integers kept as int64/uint64.
+type binaryDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+const maxBinaryDepth = 1000
+
+func (d *binaryDecoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errShortData
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *binaryDecoder) readByte() (byte, error) {
+	b, e := d.next(1)
+	if e != nil {
+		return 0, e
+	}
+	return b[0], nil
+}
+
+func (d *binaryDecoder) readUint(n int) (uint64, error) {
+	b, e := d.next(n)
+	if e != nil {
+		return 0, e
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (d *binaryDecoder) enter() error {
+	d.depth++
+	if d.depth > maxBinaryDepth {
+		return errors.New(`data nested too deeply`)
+	}
+	return nil
+}
+
+// length validates a container length against the remaining data
+func (d *binaryDecoder) length(n uint64) (int, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, errShortData
+	}
+	return int(n), nil
+}
+
+func (d *binaryDecoder) msgpack() (interface{}, error) {
+	t, e := d.readByte()
+	if e != nil {
+		return nil, e
+	}
+
+	switch {
+	case t <= 0x7f:
+		return int64(t), nil
+	case t >= 0xe0:
+		return int64(int8(t)), nil
+	case t >= 0x80 && t <= 0x8f:
+		return d.msgpackMap(uint64(t & 0x0f))
+	case t >= 0x90 && t <= 0x9f:
+		return d.msgpackArray(uint64(t & 0x0f))
+	case t >= 0xa0 && t <= 0xbf:
+		return d.str(uint64(t & 0x1f))
+	}
+
+	switch t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, e := d.readUint(1 << (t - 0xc4))
+		if e != nil {
+			return nil, e
+		}
+		return d.bin(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, e := d.readUint(1 << (t - 0xc7))
+		if e != nil {
+			return nil, e
+		}
+		return d.ext(n)
+	case 0xca:
+		u, e := d.readUint(4)
+		return math.Float32frombits(uint32(u)), e
+	case 0xcb:
+		u, e := d.readUint(8)
+		return math.Float64frombits(u), e
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, e := d.readUint(1 << (t - 0xcc))
+		if e != nil || u > math.MaxInt64 {
+			return u, e
+		}
+		return int64(u), nil
+	case 0xd0:
+		u, e := d.readUint(1)
+		return int64(int8(u)), e
+	case 0xd1:
+		u, e := d.readUint(2)
+		return int64(int16(u)), e
+	case 0xd2:
+		u, e := d.readUint(4)
+		return int64(int32(u)), e
+	case 0xd3:
+		u, e := d.readUint(8)
+		return int64(u), e
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (t - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, e := d.readUint(1 << (t - 0xd9))
+		if e != nil {
+			return nil, e
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, e := d.readUint(2 << (t - 0xdc))
+		if e != nil {
+			return nil, e
+		}
+		return d.msgpackArray(n)
+	case 0xde, 0xdf:
+		n, e := d.readUint(2 << (t - 0xde))
+		if e != nil {
+			return nil, e
+		}
+		return d.msgpackMap(n)
+	}
+	return nil, fmt.Errorf(`invalid msgpack type 0x%02x`, t)
+}
+
+func (d *binaryDecoder) msgpackArray(n uint64) (interface{}, error) {
+	return d.array(n, d.msgpack)
+}
+
+func (d *binaryDecoder) msgpackMap(n uint64) (interface{}, error) {
+	return d.object(n, d.msgpack)
+}
+
+func (d *binaryDecoder) cbor() (interface{}, error) {
+	t, e := d.readByte()
+	if e != nil {
+		return nil, e
+	}
+	major, info := t>>5, t&0x1f
+
+	if major == 7 {
+		return d.cborSimple(info)
+	}
+
+	indefinite := info == 31
+	var n uint64
+	if !indefinite {
+		if n, e = d.cborArg(info); e != nil {
+			return nil, e
+		}
+	} else if major < 2 || major == 6 {
+		return nil, fmt.Errorf(`invalid cbor indefinite length for major type %d`, major)
+	}
+
+	switch major {
+	case 0:
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 1:
+		if n > math.MaxInt64 {
+			return nil, errors.New(`cbor negative integer overflows int64`)
+		}
+		return -1 - int64(n), nil
+	case 2, 3:
+		var b []byte
+		if indefinite {
+			if b, e = d.cborChunks(major); e != nil {
+				return nil, e
+			}
+		} else {
+			l, e := d.length(n)
+			if e != nil {
+				return nil, e
+			}
+			b, _ = d.next(l)
+		}
+		if major == 2 {
+			return base64.StdEncoding.EncodeToString(b), nil
+		}
+		return string(b), nil
+	case 4:
+		if indefinite {
+			return d.cborIndefinite(false)
+		}
+		return d.array(n, d.cbor)
+	case 5:
+		if indefinite {
+			return d.cborIndefinite(true)
+		}
+		return d.object(n, d.cbor)
+	}
+	// major 6: tags are ignored, the tagged value is used as is
+	if e := d.enter(); e != nil {
+		return nil, e
+	}
+	defer func() { d.depth-- }()
+	return d.cbor()
+}
+
+func (d *binaryDecoder) cborArg(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return d.readUint(1 << (info - 24))
+	}
+	return 0, fmt.Errorf(`invalid cbor additional info %d`, info)
+}
+
+func (d *binaryDecoder) cborSimple(info byte) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		u, e := d.readUint(2)
+		return halfFloat(uint16(u)), e
+	case 26:
+		u, e := d.readUint(4)
+		return math.Float32frombits(uint32(u)), e
+	case 27:
+		u, e := d.readUint(8)
+		return math.Float64frombits(u), e
+	}
+	return nil, fmt.Errorf(`unsupported cbor simple value %d`, info)
+}
+
+// cborChunks joins the chunks of an indefinite length byte or text string
+func (d *binaryDecoder) cborChunks(major byte) ([]byte, error) {
+	var out []byte
+	for {
+		t, e := d.readByte()
+		if e != nil {
+			return nil, e
+		}
+		if t == 0xff {
+			return out, nil
+		}
+		if t>>5 != major || t&0x1f == 31 {
+			return nil, errors.New(`invalid cbor string chunk`)
+		}
+		n, e := d.cborArg(t & 0x1f)
+		if e != nil {
+			return nil, e
+		}
+		l, e := d.length(n)
+		if e != nil {
+			return nil, e
+		}
+		b, _ := d.next(l)
+		out = append(out, b...)
+	}
+}
+
+func (d *binaryDecoder) cborIndefinite(isMap bool) (interface{}, error) {
+	if e := d.enter(); e != nil {
+		return nil, e
+	}
+	defer func() { d.depth-- }()
+
+	arr := []interface{}{}
+	obj := map[string]interface{}{}
+	for {
+		if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+			d.pos++
+			if isMap {
+				return obj, nil
+			}
+			return arr, nil
+		}
+
+		v, e := d.cbor()
+		if e != nil {
+			return nil, e
+		}
+		if !isMap {
+			arr = append(arr, v)
+			continue
+		}
+		val, e := d.cbor()
+		if e != nil {
+			return nil, e
+		}
+		obj[scalarString(v)] = val
+	}
+}
+
+func (d *binaryDecoder) array(n uint64, decode func() (interface{}, error)) (interface{}, error) {
+	l, e := d.length(n)
+	if e != nil {
+		return nil, e
+	}
+	if e := d.enter(); e != nil {
+		return nil, e
+	}
+	defer func() { d.depth-- }()
+
+	arr := make([]interface{}, 0, l)
+	for i := 0; i < l; i++ {
+		v, e := decode()
+		if e != nil {
+			return nil, e
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+func (d *binaryDecoder) object(n uint64, decode func() (interface{}, error)) (interface{}, error) {
+	l, e := d.length(n)
+	if e != nil {
+		return nil, e
+	}
+	if e := d.enter(); e != nil {
+		return nil, e
+	}
+	defer func() { d.depth-- }()
+
+	obj := make(map[string]interface{}, l)
+	for i := 0; i < l; i++ {
+		k, e := decode()
+		if e != nil {
+			return nil, e
+		}
+		v, e := decode()
+		if e != nil {
+			return nil, e
+		}
+		obj[scalarString(k)] = v
+	}
+	return obj, nil
+}
+
+func (d *binaryDecoder) str(n uint64) (interface{}, error) {
+	l, e := d.length(n)
+	if e != nil {
+		return nil, e
+	}
+	b, _ := d.next(l)
+	return string(b), nil
+}
+
+func (d *binaryDecoder) bin(n uint64) (interface{}, error) {
+	l, e := d.length(n)
+	if e != nil {
+		return nil, e
+	}
+	b, _ := d.next(l)
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// ext skips the type byte and returns the payload of a msgpack extension
+func (d *binaryDecoder) ext(n uint64) (interface{}, error) {
+	if _, e := d.readByte(); e != nil {
+		return nil, e
+	}
+	return d.bin(n)
+}
+
+func halfFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+
+	switch exp {
+	case 0:
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			return -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | frac<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+}
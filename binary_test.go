@@ -0,0 +1,280 @@
+package config
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// binaryValue is the value the encoders below write and the decoders must
+// read back unchanged
+var binaryValue = map[string]interface{}{
+	`name`:  `demo`,
+	`empty`: ``,
+	`long`:  strings.Repeat(`x`, 300),
+	`port`:  int64(8080),
+	`neg`:   int64(-40000),
+	`small`: int64(-3),
+	`big`:   uint64(math.MaxUint64),
+	`ratio`: 0.25,
+	`on`:    true,
+	`off`:   false,
+	`none`:  nil,
+	`list`:  []interface{}{int64(1), `two`, []interface{}{}},
+	`db`: map[string]interface{}{
+		`hosts`: []interface{}{`a`, `b`},
+		`opts`:  map[string]interface{}{},
+	},
+}
+
+func msgpackEncode(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int64:
+		switch {
+		case v >= 0 && v <= 0x7f:
+			buf.WriteByte(byte(v))
+		case v >= -32 && v < 0:
+			buf.WriteByte(byte(int8(v)))
+		default:
+			buf.WriteByte(0xd3)
+			binary.Write(buf, binary.BigEndian, v)
+		}
+	case uint64:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, v)
+	case float64:
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case string:
+		if len(v) < 32 {
+			buf.WriteByte(0xa0 | byte(len(v)))
+		} else {
+			buf.WriteByte(0xda)
+			binary.Write(buf, binary.BigEndian, uint16(len(v)))
+		}
+		buf.WriteString(v)
+	case []interface{}:
+		buf.WriteByte(0xdc)
+		binary.Write(buf, binary.BigEndian, uint16(len(v)))
+		for _, item := range v {
+			msgpackEncode(buf, item)
+		}
+	case map[string]interface{}:
+		buf.WriteByte(0xde)
+		binary.Write(buf, binary.BigEndian, uint16(len(v)))
+		for _, k := range sortedKeys(v) {
+			msgpackEncode(buf, k)
+			msgpackEncode(buf, v[k])
+		}
+	}
+}
+
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{major<<5 | 24, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+func cborEncode(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int64:
+		if v < 0 {
+			cborHead(buf, 1, uint64(-1-v))
+		} else {
+			cborHead(buf, 0, uint64(v))
+		}
+	case uint64:
+		cborHead(buf, 0, v)
+	case float64:
+		buf.WriteByte(0xfb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case string:
+		cborHead(buf, 3, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		cborHead(buf, 4, uint64(len(v)))
+		for _, item := range v {
+			cborEncode(buf, item)
+		}
+	case map[string]interface{}:
+		cborHead(buf, 5, uint64(len(v)))
+		for _, k := range sortedKeys(v) {
+			cborEncode(buf, k)
+			cborEncode(buf, v[k])
+		}
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func decodeMsgpack(data []byte) (interface{}, error) {
+	return (&binaryDecoder{data: data}).msgpack()
+}
+
+func decodeCBOR(data []byte) (interface{}, error) {
+	return (&binaryDecoder{data: data}).cbor()
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for name, format := range map[string]struct {
+		encode func(*bytes.Buffer, interface{})
+		decode func([]byte) (interface{}, error)
+	}{
+		`msgpack`: {msgpackEncode, decodeMsgpack},
+		`cbor`:    {cborEncode, decodeCBOR},
+	} {
+		var buf bytes.Buffer
+		format.encode(&buf, binaryValue)
+		got, e := format.decode(buf.Bytes())
+		if e != nil {
+			t.Errorf(`%s: %v`, name, e)
+			continue
+		}
+		if !reflect.DeepEqual(got, binaryValue) {
+			t.Errorf(`%s: decoded %v, want %v`, name, got, binaryValue)
+		}
+
+		// every prefix of a valid encoding is truncated data
+		data := buf.Bytes()
+		for i := 0; i < len(data); i++ {
+			if _, e := format.decode(data[:i]); e == nil {
+				t.Errorf(`%s: decoding the first %d of %d bytes did not fail`, name, i, len(data))
+				break
+			}
+		}
+	}
+}
+
+func TestBinaryDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func([]byte) (interface{}, error)
+		data   []byte
+		want   interface{}
+	}{
+		{`msgpack uint8`, decodeMsgpack, []byte{0xcc, 0xff}, int64(255)},
+		{`msgpack int16`, decodeMsgpack, []byte{0xd1, 0xff, 0x00}, int64(-256)},
+		{`msgpack float32`, decodeMsgpack, []byte{0xca, 0x3f, 0xc0, 0, 0}, float32(1.5)},
+		{`msgpack str8`, decodeMsgpack, []byte{0xd9, 2, 'h', 'i'}, `hi`},
+		{`msgpack bin as base64`, decodeMsgpack, []byte{0xc4, 3, 1, 2, 3}, `AQID`},
+		{`msgpack fixext payload`, decodeMsgpack, []byte{0xd4, 0x01, 0xff}, `/w==`},
+		{`msgpack int key`, decodeMsgpack, []byte{0x81, 0x01, 0xa1, 'a'}, map[string]interface{}{`1`: `a`}},
+		{`cbor half float`, decodeCBOR, []byte{0xf9, 0x3c, 0x00}, float32(1)},
+		{`cbor undefined`, decodeCBOR, []byte{0xf7}, nil},
+		{`cbor bytes as base64`, decodeCBOR, []byte{0x43, 1, 2, 3}, `AQID`},
+		{`cbor tag ignored`, decodeCBOR, []byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0}, int64(1363896240)},
+		{`cbor nested tags`, decodeCBOR, []byte{0xd8, 0x20, 0xc0, 0x61, 'x'}, `x`},
+		{`cbor indefinite text`, decodeCBOR, []byte{0x7f, 0x62, 'a', 'b', 0x61, 'c', 0xff}, `abc`},
+		{`cbor indefinite array`, decodeCBOR, []byte{0x9f, 0x01, 0x02, 0xff}, []interface{}{int64(1), int64(2)}},
+		{`cbor indefinite map`, decodeCBOR, []byte{0xbf, 0x61, 'a', 0xf5, 0xff}, map[string]interface{}{`a`: true}},
+	}
+
+	for _, tt := range tests {
+		got, e := tt.decode(tt.data)
+		if e != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf(`%s: got %#v, %v, want %#v`, tt.name, got, e, tt.want)
+		}
+	}
+}
+
+func TestBinaryMalformed(t *testing.T) {
+	deep := append(bytes.Repeat([]byte{0x91}, maxBinaryDepth+1), 0x01)
+
+	tests := []struct {
+		name   string
+		decode func([]byte) (interface{}, error)
+		data   []byte
+		want   string
+	}{
+		{`msgpack empty`, decodeMsgpack, nil, errShortData.Error()},
+		{`msgpack reserved type`, decodeMsgpack, []byte{0xc1}, `invalid msgpack type 0xc1`},
+		{`msgpack oversized str32`, decodeMsgpack, []byte{0xdb, 0xff, 0xff, 0xff, 0xff, 'a'}, errShortData.Error()},
+		{`msgpack oversized map32`, decodeMsgpack, []byte{0xdf, 0xff, 0xff, 0xff, 0xff}, errShortData.Error()},
+		{`msgpack oversized array32`, decodeMsgpack, []byte{0xdd, 0x7f, 0xff, 0xff, 0xff, 0x01}, errShortData.Error()},
+		{`msgpack oversized bin32`, decodeMsgpack, []byte{0xc6, 0xff, 0xff, 0xff, 0xff}, errShortData.Error()},
+		{`msgpack oversized ext`, decodeMsgpack, []byte{0xc7, 0xff, 0x01}, errShortData.Error()},
+		{`msgpack nested too deeply`, decodeMsgpack, deep, `nested too deeply`},
+		{`cbor oversized text`, decodeCBOR, []byte{0x7b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, errShortData.Error()},
+		{`cbor oversized map`, decodeCBOR, []byte{0xba, 0xff, 0xff, 0xff, 0xff}, errShortData.Error()},
+		{`cbor oversized chunk`, decodeCBOR, []byte{0x5f, 0x5a, 0xff, 0xff, 0xff, 0xff, 0xff}, errShortData.Error()},
+		{`cbor reserved additional info`, decodeCBOR, []byte{0x1c}, `invalid cbor additional info 28`},
+		{`cbor unsupported simple value`, decodeCBOR, []byte{0xf0}, `unsupported cbor simple value 16`},
+		{`cbor indefinite integer`, decodeCBOR, []byte{0x1f}, `invalid cbor indefinite length for major type 0`},
+		{`cbor indefinite tag`, decodeCBOR, []byte{0xdf, 0x01}, `invalid cbor indefinite length for major type 6`},
+		{`cbor mixed string chunk`, decodeCBOR, []byte{0x7f, 0x41, 'a', 0xff}, `invalid cbor string chunk`},
+		{`cbor unterminated indefinite array`, decodeCBOR, []byte{0x9f, 0x01}, errShortData.Error()},
+		{`cbor negative overflow`, decodeCBOR, []byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, `overflows int64`},
+		{`cbor tags nested too deeply`, decodeCBOR, append(bytes.Repeat([]byte{0xc0}, maxBinaryDepth+1), 0x01), `nested too deeply`},
+	}
+
+	for _, tt := range tests {
+		_, e := tt.decode(tt.data)
+		if e == nil || !strings.Contains(e.Error(), tt.want) {
+			t.Errorf(`%s: got %v, want %q`, tt.name, e, tt.want)
+		}
+	}
+}
+
+func TestReadBinaryTopLevelMap(t *testing.T) {
+	quietStdout(t)
+
+	var buf bytes.Buffer
+	msgpackEncode(&buf, binaryValue)
+	c := &Config{}
+	if e := c.LoadBytes(buf.Bytes(), `msgpack`); e != nil {
+		t.Fatal(e)
+	}
+	if got := c.GetString(`db.hosts.1`); got != `b` {
+		t.Errorf(`db.hosts.1 = %q`, got)
+	}
+
+	buf.Reset()
+	cborEncode(&buf, []interface{}{int64(1)})
+	e := c.LoadBytes(buf.Bytes(), `cbor`)
+	if e == nil || !strings.Contains(e.Error(), `top level value must be a map`) {
+		t.Errorf(`LoadBytes(cbor array) = %v`, e)
+	}
+	e = c.LoadBytes([]byte{0xdf, 0xff, 0xff, 0xff, 0xff}, `msgpack`)
+	if e == nil || !strings.Contains(e.Error(), errShortData.Error()) {
+		t.Errorf(`LoadBytes(oversized map) = %v`, e)
+	}
+}
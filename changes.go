@@ -0,0 +1,177 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change describes one property changed by a reload
+type Change struct {
+	Key string `json:"key"`
+	Op  string `json:"op"` // "add", "update" or "delete"
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// diffStorage returns the changes from old to new sorted by key
+func diffStorage(old, new map[string]string) []Change {
+	var changes []Change
+	for k, v := range new {
+		if prev, ok := old[k]; !ok {
+			changes = append(changes, Change{Key: k, Op: `add`, New: v})
+		} else if prev != v {
+			changes = append(changes, Change{Key: k, Op: `update`, Old: prev, New: v})
+		}
+	}
+	for k, v := range old {
+		if _, ok := new[k]; !ok {
+			changes = append(changes, Change{Key: k, Op: `delete`, Old: v})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+type prefixWatcher struct {
+	prefix string
+	fn     func([]Change)
+}
+
+// Call handler with the changed properties whose name starts with prefix,
+// after every Reload or Set that changed at least one of them
+func (c *Config) WatchPrefix(prefix string, handler func(changes []Change)) {
+	if c.parent != nil {
+		c.parent.WatchPrefix(c.subPrefix+normalizeKey(prefix), func(changes []Change) {
+			handler(c.subChanges(changes))
+		})
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prefixWatchers = append(c.prefixWatchers, prefixWatcher{prefix: normalizeKey(prefix), fn: handler})
+}
+
+// publish delivers applied changes to the change feed and prefix watchers
+func (c *Config) publish(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	c.writeChangeFeed(changes)
+	c.postWebhooks(changes)
+
+	c.mu.RLock()
+	watchers := append([]prefixWatcher(nil), c.prefixWatchers...)
+	c.mu.RUnlock()
+
+	for _, w := range watchers {
+		var matched []Change
+		for _, ch := range changes {
+			if strings.HasPrefix(ch.Key, w.prefix) {
+				matched = append(matched, ch)
+			}
+		}
+		if len(matched) > 0 {
+			w.fn(matched)
+		}
+	}
+}
+
+type changeFeedLine struct {
+	Time    time.Time `json:"time"`
+	Files   []string  `json:"files"`
+	Changes []Change  `json:"changes"`
+}
+
+// Append every change set applied by Reload or Set as one JSON line to path, which
+// may be a regular file or a named pipe. Values of secret properties are
+// masked and long values cut, see SetMaxValueLength. Lines are written in
+// the background so a pipe without reader does not block Reload. An empty path disables the feed.
+func (c *Config) SetChangeFeed(path string) {
+	if c.parent != nil {
+		c.parent.SetChangeFeed(path)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.feedPath = path
+	if path != `` && c.feed == nil {
+		c.feed = make(chan feedItem, 64)
+		go runChangeFeed(c.feed)
+	}
+}
+
+type feedItem struct {
+	path string
+	line []byte
+}
+
+func runChangeFeed(items <-chan feedItem) {
+	for item := range items {
+		fi, e := os.OpenFile(item.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if e != nil {
+			fmt.Println(`Write config change feed failed:`, e)
+			continue
+		}
+		if _, e := fi.Write(item.line); e != nil {
+			fmt.Println(`Write config change feed failed:`, e)
+		}
+		fi.Close()
+	}
+}
+
+func (c *Config) writeChangeFeed(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	c.mu.RLock()
+	path, feed := c.feedPath, c.feed
+	c.mu.RUnlock()
+
+	if path == `` {
+		return
+	}
+	line := c.changeLine(changes)
+
+	data, e := json.Marshal(line)
+	if e != nil {
+		fmt.Println(`Write config change feed failed:`, e)
+		return
+	}
+
+	select {
+	case feed <- feedItem{path: path, line: append(data, '\n')}:
+	default:
+		fmt.Println(`Config change feed is full, dropped change set`)
+	}
+}
+
+// changeLine returns changes for logs and notifications, with secret values
+// masked and long values cut
+func (c *Config) changeLine(changes []Change) changeFeedLine {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	line := changeFeedLine{Time: c.now(), Files: append([]string(nil), c.file...)}
+	for _, ch := range changes {
+		ch.Old, ch.New = c.displayValue(ch.Key, ch.Old), c.displayValue(ch.Key, ch.New)
+		line.Changes = append(line.Changes, ch)
+	}
+	return line
+}
+
+func maskValue(val string) string {
+	if val == `` {
+		return ``
+	}
+	return secretMask
+}
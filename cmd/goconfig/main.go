@@ -0,0 +1,169 @@
+// Command goconfig works with go-config files outside of a running program.
+//
+// Usage:
+//
+//	goconfig compile -o app.cfgc [-schema schema.conf] app.conf [more.conf ...]
+//	goconfig check [-schema schema.conf] app.conf [more.conf ...]
+//	goconfig keys [-tree] app.conf [more.conf ...]
+//
+// compile reads the config files the same way Config.Open does (includes,
+// overrides between files, environment variables and references) and
+// writes a single compiled file that the program loads with
+// Config.OpenCompiled. Nothing is written when check would report a
+// problem.
+//
+// check reads the config files with Config.SelfTest, prints the report and
+// exits with status 1 when a problem was found.
+//
+// The -schema file lists the checked properties, one per line with the
+// words required and/or a kind (string, int, float or bool):
+//
+//	[db]
+//	host = required
+//	port = required int
+//
+// keys lists the property names, or with -tree the objects and arrays they
+// form (see Config.Shape).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	config "github.com/budimanlai/go-config"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var e error
+	switch os.Args[1] {
+	case "compile":
+		e = compile(os.Args[2:])
+	case "check":
+		e = check(os.Args[2:])
+	case "keys":
+		e = keys(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if e != nil {
+		fmt.Fprintln(os.Stderr, "goconfig:", e)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: goconfig compile -o output [-schema file] file...")
+	fmt.Fprintln(os.Stderr, "       goconfig check [-schema file] file...")
+	fmt.Fprintln(os.Stderr, "       goconfig keys [-tree] file...")
+	os.Exit(2)
+}
+
+func compile(args []string) error {
+	fs := flag.NewFlagSet("compile", flag.ExitOnError)
+	output := fs.String("o", "", "compiled config output file")
+	schema := fs.String("schema", "", "schema file the config is checked against")
+	fs.Parse(args)
+
+	if *output == "" || fs.NArg() == 0 {
+		usage()
+	}
+
+	var c config.Config
+	if e := useSchema(&c, *schema); e != nil {
+		return e
+	}
+	if e := c.Open(fs.Args()...); e != nil {
+		return e
+	}
+	return c.WriteCompiled(*output)
+}
+
+func check(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	schema := fs.String("schema", "", "schema file the config is checked against")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		usage()
+	}
+
+	var c config.Config
+	if e := useSchema(&c, *schema); e != nil {
+		return e
+	}
+	if e := c.Open(fs.Args()...); e != nil {
+		return e
+	}
+
+	r := c.SelfTest()
+	fmt.Print(r)
+	if !r.OK() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// useSchema declares the required properties and kinds listed in the schema
+// file path on c
+func useSchema(c *config.Config, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	var schema config.Config
+	if e := schema.Open(path); e != nil {
+		return e
+	}
+
+	for name, rule := range schema.GetAll() {
+		for _, word := range strings.Fields(rule) {
+			if word == "required" {
+				c.Require(name)
+				continue
+			}
+			if e := c.DeclareType(name, config.Kind(word)); e != nil {
+				return fmt.Errorf("%s: %s: %v", path, name, e)
+			}
+		}
+	}
+	return nil
+}
+
+func keys(args []string) error {
+	fs := flag.NewFlagSet("keys", flag.ExitOnError)
+	tree := fs.Bool("tree", false, "print the property tree")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		usage()
+	}
+
+	var c config.Config
+	if e := c.Open(fs.Args()...); e != nil {
+		return e
+	}
+
+	if *tree {
+		fmt.Print(c.Shape())
+		return nil
+	}
+
+	all := c.GetAll()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
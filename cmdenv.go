@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// SecretEnv selects how CommandEnv passes secret properties
+type SecretEnv int
+
+const (
+	// Secret properties are left out
+	SecretEnvOmit SecretEnv = iota
+
+	// Secret properties are passed as ******, so the worker sees they exist
+	SecretEnvMask
+
+	// Secret properties are passed with their value
+	SecretEnvPass
+)
+
+// CommandEnvOptions configures CommandEnvWith
+type CommandEnvOptions struct {
+	// Variables are named Prefix + "_" + DATABASE_HOST, without prefix when
+	// blank
+	Prefix string
+
+	// Allow lists the properties passed: a name includes the properties
+	// under it, * matches any single part (workers.*.queue). Nothing is
+	// passed without it.
+	Allow []string
+
+	// Secrets selects how secret properties are passed, left out by default
+	Secrets SecretEnv
+
+	// Inherit starts from the environment of the current process, the
+	// properties replace variables of the same name
+	Inherit bool
+}
+
+// Return the allowed properties as NAME=value environment variables for
+// exec.Cmd.Env, e.g. CommandEnv("WORKER", "database", "queue.url") passes
+// database.host as WORKER_DATABASE_HOST. Secret properties are left out.
+func (c *Config) CommandEnv(prefix string, allowlist ...string) []string {
+	return c.CommandEnvWith(CommandEnvOptions{Prefix: prefix, Allow: allowlist})
+}
+
+// Return the allowed properties as environment variables for exec.Cmd.Env,
+// configured by opts
+func (c *Config) CommandEnvWith(opts CommandEnvOptions) []string {
+	prefix := strings.ToUpper(strings.TrimSuffix(opts.Prefix, "_"))
+	if prefix != `` {
+		prefix += "_"
+	}
+
+	allow := make([][]string, 0, len(opts.Allow))
+	for _, a := range opts.Allow {
+		allow = append(allow, SplitKey(strings.TrimPrefix(normalizeKey(a), ".")))
+	}
+
+	all := c.GetAll()
+	root, subPrefix := c.root()
+	root.mu.RLock()
+	vars := make(map[string]string)
+	for k, v := range all {
+		if !envAllowed(allow, SplitKey(strings.TrimPrefix(k, "."))) {
+			continue
+		}
+		if root.secrets[subPrefix+k] {
+			switch opts.Secrets {
+			case SecretEnvOmit:
+				continue
+			case SecretEnvMask:
+				v = secretMask
+			}
+		}
+		vars[prefix+envName(k)] = v
+	}
+	root.mu.RUnlock()
+
+	var env []string
+	if opts.Inherit {
+		for _, kv := range os.Environ() {
+			name, _, _ := strings.Cut(kv, "=")
+			if _, ok := vars[name]; !ok {
+				env = append(env, kv)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+vars[name])
+	}
+	return env
+}
+
+// envAllowed reports whether the key parts are matched by an allowlist
+// entry or are under one
+func envAllowed(allow [][]string, parts []string) bool {
+	for _, pattern := range allow {
+		if len(pattern) <= len(parts) && matchKeyPattern(pattern, parts[:len(pattern)]) {
+			return true
+		}
+	}
+	return false
+}
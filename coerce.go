@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CoerceFunc prepares the stored text val of property key for conversion to
+// kind, returning the text handed to the parser of that kind or an error
+// when val must not be read as kind. KindInt also covers the unsigned
+// getters; GetDuration, GetTime and GetSizeBytes pass KindDuration, KindTime
+// and KindSize, and GetURL, GetIP, GetCIDR and GetLocation pass KindString.
+// It is called for every typed getter, the slice getters, struct decoding
+// and values of types declared with DeclareType, the latter while the
+// Config is locked, so it must not call methods of the Config.
+//
+// The built-in matrix, by stored text and requested kind:
+//
+//	stored           int     float   bool
+//	"42"             42      42      error
+//	" 42 "           42 (1)  42 (1)  error
+//	"1", "0"         1, 0    1, 0    true, false (1)
+//	"t", "F", "TRUE" error   error   true, false, true (1)
+//	"true", "false"  error   error   true, false
+//	"4.2"            error   4.2     error
+//
+// (1) CoerceLenient only. Strings accept every value as is. Durations, times
+// and sizes are trimmed by CoerceLenient and rejected with surrounding spaces
+// by CoerceStrict, like integers.
+type CoerceFunc func(key string, val string, kind Kind) (string, error)
+
+// CoerceLenient is the default CoerceFunc. It trims spaces and accepts every
+// form strconv.ParseBool accepts, so "1" and "0" are booleans too.
+func CoerceLenient(key string, val string, kind Kind) (string, error) {
+	if kind == KindString {
+		return val, nil
+	}
+	return strings.TrimSpace(val), nil
+}
+
+// CoerceStrict is a CoerceFunc that does not convert across kinds: booleans
+// must be "true" or "false" and surrounding spaces are rejected. Integers
+// are still valid floats.
+func CoerceStrict(key string, val string, kind Kind) (string, error) {
+	switch kind {
+	case KindString:
+		return val, nil
+	case KindBool:
+		if val != `true` && val != `false` {
+			return ``, fmt.Errorf(`%q is not true or false`, val)
+		}
+	default:
+		if val != strings.TrimSpace(val) {
+			return ``, fmt.Errorf(`%q has surrounding spaces`, val)
+		}
+	}
+	return val, nil
+}
+
+// Set how stored text is converted by the typed getters, the slice getters,
+// MapToStructNested and GetArrayToStruct, e.g. SetCoercion(CoerceStrict).
+// A nil fn restores CoerceLenient.
+func (c *Config) SetCoercion(fn CoerceFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.coerce = fn
+}
+
+// coercion returns the CoerceFunc in use. Caller must hold c.mu.
+func (c *Config) coercion() CoerceFunc {
+	if c.coerce == nil {
+		return CoerceLenient
+	}
+	return c.coerce
+}
+
+// parseKind calls parse with the value of property name coerced to kind
+func (c *Config) parseKind(name string, kind Kind, parse func(string) error) error {
+	c.mu.RLock()
+	coerce := c.coercion()
+	c.mu.RUnlock()
+
+	key := normalizeKey(name)
+	return c.parseProperty(name, func(s string) error {
+		s, e := coerce(key, s, kind)
+		if e != nil {
+			return e
+		}
+		return parse(s)
+	})
+}
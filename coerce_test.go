@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoercionAppliesToEveryGetter(t *testing.T) {
+	c := &Config{}
+	c.LoadMap(map[string]string{
+		`a.bool`:     ` true `,
+		`a.duration`: ` 5s `,
+		`a.time`:     ` 2024-05-01 `,
+		`a.size`:     ` 10MB `,
+	}, MapOptions{})
+
+	var b bool
+	var d time.Duration
+	if e := c.Fetch(Field{`a.bool`, &b}, Field{`a.duration`, &d}); e != nil || !b || d != 5*time.Second {
+		t.Errorf(`lenient Fetch = %v, %v, %v`, e, b, d)
+	}
+	if got := c.GetDuration(`a.duration`); got != 5*time.Second {
+		t.Errorf(`lenient GetDuration = %v`, got)
+	}
+	if got := c.GetTime(`a.time`); got.IsZero() {
+		t.Error(`lenient GetTime returned the zero time`)
+	}
+	if got := c.GetSizeBytes(`a.size`); got != 10e6 {
+		t.Errorf(`lenient GetSizeBytes = %d`, got)
+	}
+
+	c.SetCoercion(CoerceStrict)
+	if e := c.Fetch(Field{`a.bool`, &b}); e == nil {
+		t.Error(`strict Fetch accepted " true "`)
+	}
+	if _, e := c.GetDurationE(`a.duration`); e == nil {
+		t.Error(`strict GetDurationE accepted " 5s "`)
+	}
+	if _, e := c.GetTimeE(`a.time`); e == nil {
+		t.Error(`strict GetTimeE accepted " 2024-05-01 "`)
+	}
+	if _, e := c.GetSizeBytesE(`a.size`); e == nil {
+		t.Error(`strict GetSizeBytesE accepted " 10MB "`)
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+// Write the properties into a compiled config file. The file holds every
+// property as Get returns it: includes, LoadMap layers, overrides and
+// defaults applied, environment variables, _file references, resolvers and
+// SetInterpolate references resolved. OpenCompiled loads it as is, without
+// any of that resolution logic. Secrets read by references are stored too,
+// so the file is written readable by its owner only, and stay marked secret
+// when it is opened. Nothing is written when SelfTest finds a problem; the
+// error is a *ValidationError.
+func (c *Config) WriteCompiled(path string) error {
+	if c.parent != nil {
+		return c.parent.WriteCompiled(path)
+	}
+
+	if r := c.SelfTest(); !r.OK() {
+		return &ValidationError{Problems: r.Problems}
+	}
+
+	all := c.GetAll()
+	for name := range all {
+		if val, ok := c.lookup(name); ok {
+			all[name] = val
+		}
+	}
+
+	c.mu.RLock()
+	s := snapshot{Storage: all}
+	for name := range c.secrets {
+		s.Secrets = append(s.Secrets, name)
+	}
+	fsys := c.filesystem()
+	c.mu.RUnlock()
+
+	return writeGob(fsys, path, &s)
+}
+
+// Read compiled config file written by WriteCompiled or `goconfig compile`.
+// The properties are loaded as stored: nothing is expanded or resolved, and
+// only environment variables bound with BindEnv or BindEnvPrefix are still
+// read by Get. SetInterpolate is turned off, the values are interpolated
+// already.
+func (c *Config) OpenCompiled(path string) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	if e := c.checkNotClosed(); e != nil {
+		return e
+	}
+
+	c.mu.RLock()
+	fsys := c.filesystem()
+	c.mu.RUnlock()
+
+	fi, e := fsys.Open(path)
+	if e != nil {
+		return e
+	}
+	defer fi.Close()
+
+	var s snapshot
+	if e := gob.NewDecoder(fi).Decode(&s); e != nil {
+		return fmt.Errorf(`%s: invalid compiled config: %v`, path, e)
+	}
+	if s.Storage == nil {
+		return errors.New(path + `: compiled config has no properties`)
+	}
+
+	fmt.Println(`Read compiled config:`, path)
+	c.MarkSecret(s.Secrets...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.storage = s.Storage
+	c.file = []string{path}
+	c.sources = nil
+	c.interpolate = false
+	return nil
+}
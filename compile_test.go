@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompiledConfigIsFrozen(t *testing.T) {
+	quietStdout(t)
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, `password`)
+	if e := os.WriteFile(secret, []byte("s3cret-value"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	src := filepath.Join(dir, `app.ini`)
+	data := "[db]\nhost = ${COMPILE_TEST_HOST}\npassword_file = " + secret + "\nurl = ${db.host}:5432\n"
+	if e := os.WriteFile(src, []byte(data), 0600); e != nil {
+		t.Fatal(e)
+	}
+	t.Setenv(`COMPILE_TEST_HOST`, `db.internal`)
+
+	c := &Config{}
+	c.SetExpandEnv(true)
+	c.SetInterpolate(true)
+	c.SetFileRefs(true)
+	if e := c.Open(src); e != nil {
+		t.Fatal(e)
+	}
+	out := filepath.Join(dir, `app.cfgc`)
+	if e := c.WriteCompiled(out); e != nil {
+		t.Fatal(e)
+	}
+
+	// the runtime config resolves nothing, even with the same settings
+	t.Setenv(`COMPILE_TEST_HOST`, `changed`)
+	r := &Config{}
+	r.SetExpandEnv(true)
+	r.SetInterpolate(true)
+	r.SetFileRefs(true)
+	if e := r.OpenCompiled(out); e != nil {
+		t.Fatal(e)
+	}
+
+	want := map[string]string{
+		`db.host`:     `db.internal`,
+		`db.url`:      `db.internal:5432`,
+		`db.password`: `s3cret-value`,
+	}
+	for k, v := range want {
+		if got := r.GetString(k); got != v {
+			t.Errorf(`%s = %q, want %q`, k, got, v)
+		}
+	}
+	if !r.IsSecret(`db.password`) {
+		t.Error(`db.password is not secret after OpenCompiled`)
+	}
+}
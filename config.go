@@ -1,59 +1,572 @@
 package config
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strconv"
+	"sync"
+	"time"
 )
 
 type Config struct {
-	storage map[string]string
-	file    []string
+	mu       sync.RWMutex
+	storage  map[string]string
+	file     []string
+	sources  []string
+	onReload []*reloadHook
+
+	emptyFiles      []string
+	requireNonEmpty bool
+	origins         map[string]string // source that set each property
+	conflict        ConflictFunc
+	migrations      []Migration
+	durationSeconds bool
+	timeLayouts     []string
+	sliceSep        string
+	onAccess        AccessFunc
+	accessSkip      int
+	watchdogs       int                     // running StartWatchdog
+	fileStamps      map[string]*watchedFile // files of the last load
+	includeGlobs    map[string][]string     // include patterns read and their matches
+	descriptions    map[string]string       // comments of properties
+	deleted         map[string]bool         // properties deleted with Update
+	coerce          CoerceFunc
+	strict          bool    // report invalid values read by the Or getters
+	parent          *Config // Config read by a Sub view
+	subPrefix       string  // prefix of a Sub view, ending with a dot
+	fileRefs        bool    // read _file properties, see SetFileRefs
+
+	reloads    int
+	lastReload time.Time
+	slowHook   time.Duration
+
+	snapshotPath string
+	envKey       func(string) string
+	closed       bool
+	secrets      map[string]bool
+	resolvers    map[string]Resolver
+	maxValueLen  int
+
+	feedPath string
+	feed     chan feedItem
+	webhooks []webhook
+
+	overrides     map[string]string
+	overridesPath string
+
+	watching bool
+	formats  map[string]string
+	layers   []mapLayer
+
+	dirSources map[string]string
+	readers    map[string]*File
+	remotes    map[string]*remoteSource
+	providers  map[string]*provider
+	arrayMerge ArrayMerge
+
+	sink           RemoteSink
+	remoteVersions map[string]int64
+
+	repeatedKeyArrays bool
+	iniCompat         bool
+	iniStrict         bool
+	iniCommaArrays    bool
+	numbers           *numberFormat
+	location          *time.Location
+
+	envPrefixes []string
+	envBindings map[string]string
+	expandEnv   bool
+	interpolate bool
+
+	kinds      map[string]Kind
+	required   []string
+	validators []func(*Config) error
+	namespaces []*Namespace
+	defaults   map[string]string
+
+	fsys  FS
+	clock Clock
+
+	sourceStates map[string]*sourceState
+
+	prefixWatchers []prefixWatcher
 }
 
-// Read config file
+// Read config file. Every name is read through a Source: plain file names
+// and file:// URLs through FileSource, names with another scheme registered
+// with RegisterScheme (e.g. consul://127.0.0.1:8500/myapp/) through the
+// Source of that scheme, watched like AddSource does.
 func (c *Config) Open(file ...string) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
 	if len(file) == 0 {
 		return errors.New(`File config blank`)
 	}
+	if e := c.checkNotClosed(); e != nil {
+		return e
+	}
 
-	c.storage = make(map[string]string)
-
-	for _, obj := range file {
-		ff := NewFile(obj)
-		e := ff.Read(c)
+	providers := make(map[string]*provider)
+	for _, name := range file {
+		src, e := openSource(name)
 		if e != nil {
 			return e
 		}
+		providers[name] = &provider{src: src}
+	}
+
+	c.mu.Lock()
+	for _, p := range providers {
+		if s, ok := p.src.(*FileSource); ok && s.FS == nil {
+			s.FS = c.fsys
+		}
+	}
+	c.sources = file
+	c.dirSources = nil
+	c.readers = nil
+	c.stopRemotes()
+	c.remotes = nil
+	c.stopProviders()
+	c.providers = providers
+	c.mu.Unlock()
+
+	if _, e := c.load(); e != nil {
+		return e
+	}
+	for name, p := range providers {
+		c.startProvider(name, p)
 	}
 	return nil
 }
 
+// Read again all config files passed to Open and replace the current
+// properties. On error the current properties are kept. Functions registered
+// with OnReload are called after a successful reload.
+func (c *Config) Reload() error {
+	if c.parent != nil {
+		return c.parent.Reload()
+	}
+
+	if e := c.checkOpen(); e != nil {
+		return e
+	}
+
+	changes, e := c.load()
+	if e != nil {
+		return e
+	}
+
+	c.rewatch()
+	c.publish(changes)
+	c.runReloadHooks()
+	return nil
+}
+
+// Register function called after every successful Reload
+func (c *Config) OnReload(fn func()) {
+	if c.parent != nil {
+		c.parent.OnReload(fn)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onReload = append(c.onReload, newReloadHook(fn))
+}
+
+// load reads all sources into a fresh storage and swaps it in on success
+func (c *Config) load() ([]Change, error) {
+	c.mu.RLock()
+	set := c.sourceSet()
+	sources := set.names
+	snapshotPath := c.snapshotPath
+	settings := c.parseSettings()
+	fsys := c.filesystem()
+	c.mu.RUnlock()
+
+	if len(set.readers) > 0 || set.hasRemote() {
+		snapshotPath = `` // their content can not be checked for changes
+	}
+
+	if snapshotPath != `` {
+		if s, ok := loadSnapshot(fsys, snapshotPath, set, settings); ok {
+			if e := c.readRefs(s.Storage); e != nil {
+				e = c.scrub(e, s.Storage)
+				c.recordSources(sources, ``, e)
+				return nil, e
+			}
+			changes := c.swap(s.Storage, s.filenames())
+			c.mu.Lock()
+			c.emptyFiles = s.EmptyFiles
+			c.origins = s.Origins
+			c.descriptions = s.Descriptions
+			c.mu.Unlock()
+			c.recordSources(sources, ``, nil)
+			c.restampFiles()
+			return changes, nil
+		}
+	}
+
+	tmp, failed, e := c.readSources(set)
+	if e != nil {
+		c.recordSources(sources, failed, e)
+		return nil, e
+	}
+
+	if snapshotPath != `` {
+		if e := writeSnapshot(fsys, snapshotPath, set, settings, tmp); e != nil {
+			fmt.Println(`Write config snapshot failed:`, e)
+		}
+	}
+
+	if e := c.readRefs(tmp.storage); e != nil {
+		e = c.scrub(e, tmp.storage)
+		c.recordSources(sources, ``, e)
+		return nil, e
+	}
+
+	changes := c.swap(tmp.storage, tmp.file)
+	c.mu.Lock()
+	c.emptyFiles = tmp.emptyFiles
+	c.origins = tmp.origins
+	c.descriptions = tmp.descriptions
+	c.seedRemoteVersions(tmp.remoteVersions)
+	c.mu.Unlock()
+	c.recordSources(sources, ``, nil)
+	c.restampFiles()
+	return changes, nil
+}
+
+// sourceSet is a copy of the sources of a Config taken for one load
+type sourceSet struct {
+	names     []string
+	dirs      map[string]string
+	readers   map[string]*File
+	providers map[string]Source
+}
+
+// hasRemote reports whether a source other than a file is in the set
+func (set sourceSet) hasRemote() bool {
+	for _, src := range set.providers {
+		if !isFileSource(src) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileSources returns the file sources of source name: the FileSource
+// opened for it, the files listed by an OpenDir source or a reader source
+func (set sourceSet) fileSources(fsys FS, name string) []*FileSource {
+	if src, ok := set.providers[name].(*FileSource); ok {
+		return []*FileSource{src}
+	}
+	if r, ok := set.readers[name]; ok {
+		return []*FileSource{{Path: name, data: r.data}}
+	}
+
+	var out []*FileSource
+	for _, path := range sourceFiles(fsys, name, set.dirs) {
+		out = append(out, &FileSource{Path: path})
+	}
+	return out
+}
+
+// sourceSet returns the current sources. Caller must hold c.mu.
+func (c *Config) sourceSet() sourceSet {
+	set := sourceSet{
+		names:   c.sources,
+		dirs:    c.dirSources,
+		readers: c.readers,
+	}
+	if len(c.providers) > 0 {
+		set.providers = make(map[string]Source, len(c.providers))
+		for name, p := range c.providers {
+			set.providers[name] = p.src
+		}
+	}
+	return set
+}
+
+// readSources reads every source into a scratch Config. On error it also
+// returns the source that failed.
+func (c *Config) readSources(set sourceSet) (*Config, string, error) {
+	tmp := c.scratch()
+	tmp.includeGlobs = make(map[string][]string)
+	fsys := tmp.filesystem()
+
+	c.mu.RLock()
+	requireNonEmpty := c.requireNonEmpty
+	conflict := c.conflict
+	c.mu.RUnlock()
+
+	for _, obj := range set.names {
+		if src, ok := set.providers[obj]; ok && !isFileSource(src) {
+			props, e := src.Load(context.Background())
+			if e != nil {
+				return nil, obj, c.scrub(fmt.Errorf(`%s: %v`, obj, e), tmp.storage, props)
+			}
+			if s, ok := src.(SecretSource); ok {
+				var names []string
+				for name := range props {
+					if s.IsSecret(name) {
+						names = append(names, name)
+					}
+				}
+				c.MarkSecret(names...)
+			}
+			if s, ok := src.(RevisionSource); ok {
+				if tmp.remoteVersions == nil {
+					tmp.remoteVersions = make(map[string]int64)
+				}
+				for name, rev := range s.Revisions() {
+					tmp.remoteVersions[name] = rev
+				}
+			}
+			if conflict != nil {
+				if props, e = resolveConflicts(conflict, tmp, props, obj); e != nil {
+					return nil, obj, c.scrub(e, tmp.storage, props)
+				}
+			}
+			mergeLayer(tmp.storage, props, tmp.arrayMerge)
+			tmp.setOrigin(props, obj)
+			continue
+		}
+
+		for _, src := range set.fileSources(fsys, obj) {
+			// every file is read on its own so arrays can be merged per layer
+			name := src.Path
+			layer := c.scratch()
+			layer.file = tmp.file
+			layer.includeGlobs = tmp.includeGlobs
+
+			e := src.read(layer)
+			if e != nil {
+				return nil, obj, c.scrub(e, tmp.storage, layer.storage)
+			}
+
+			if len(layer.storage) == 0 {
+				if requireNonEmpty {
+					return nil, obj, fmt.Errorf(`%s: %w`, name, ErrEmptyFile)
+				}
+				tmp.emptyFiles = append(tmp.emptyFiles, name)
+			}
+
+			if conflict != nil {
+				if layer.storage, e = resolveConflicts(conflict, tmp, layer.storage, name); e != nil {
+					return nil, obj, c.scrub(e, tmp.storage, layer.storage)
+				}
+			}
+
+			tmp.file = layer.file
+			mergeLayer(tmp.storage, layer.storage, layer.arrayMerge)
+			tmp.setOrigin(layer.storage, name)
+			for k, d := range layer.descriptions {
+				tmp.describeKey(k, nil, d)
+			}
+		}
+	}
+	return tmp, ``, nil
+}
+
+// swap replaces the current properties and returns what changed
+func (c *Config) swap(storage map[string]string, files []string) []Change {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resolve(storage)
+	changes := diffStorage(c.storage, storage)
+	c.storage = storage
+	c.file = files
+	return changes
+}
+
+// resolve applies to the properties read from files everything configured
+// on top of them. Caller must hold c.mu.
+func (c *Config) resolve(storage map[string]string) {
+	c.applyLayers(storage)
+	c.applyOverrides(storage)
+	c.applyMigrations(storage)
+	c.applyDefaults(storage)
+}
+
+// readRefs expands environment variables when SetExpandEnv is enabled, then
+// replaces _file and resolver references in storage with the values they
+// point to. Secrets read that way are never expanded.
+func (c *Config) readRefs(storage map[string]string) error {
+	c.mu.RLock()
+	expand := c.expandEnv
+	c.mu.RUnlock()
+
+	if expand {
+		expandEnv(storage)
+	}
+	if e := c.readFileRefs(storage); e != nil {
+		return e
+	}
+	return c.applyResolvers(storage)
+}
+
+// scratch returns an empty Config carrying the parse settings of c, used to
+// read files without touching the current properties
+func (c *Config) scratch() *Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return &Config{
+		storage: make(map[string]string),
+		envKey:  c.envKey,
+		formats: c.formats,
+		fsys:    c.fsys,
+
+		repeatedKeyArrays: c.repeatedKeyArrays,
+		iniCompat:         c.iniCompat,
+		iniStrict:         c.iniStrict,
+		iniCommaArrays:    c.iniCommaArrays,
+		arrayMerge:        c.arrayMerge,
+	}
+}
+
+// lookup returns the value of property name, with references to other
+// properties expanded when SetInterpolate is enabled
+func (c *Config) lookup(name string) (string, bool) {
+	if c.parent != nil {
+		return c.parent.lookup(c.subPrefix + normalizeKey(name))
+	}
+
+	val, ok := c.lookupRaw(name)
+	if !ok {
+		return ``, false
+	}
+
+	c.mu.RLock()
+	interpolate := c.interpolate
+	c.mu.RUnlock()
+
+	if interpolate {
+		val = c.expandValue(val, map[string]bool{normalizeKey(name): true})
+	}
+	return val, true
+}
+
+// lookupRaw returns the stored value of property name
+func (c *Config) lookupRaw(name string) (string, bool) {
+	if c.parent != nil {
+		return c.parent.lookupRaw(c.subPrefix + normalizeKey(name))
+	}
+	name = normalizeKey(name)
+
+	c.mu.RLock()
+	val, ok := c.lookupEnv(name)
+	if !ok {
+		val, ok = c.storage[name]
+	}
+	onAccess, skip := c.onAccess, c.accessSkip
+	c.mu.RUnlock()
+
+	if onAccess != nil {
+		c.traceAccess(onAccess, skip, name, ok)
+	}
+	return val, ok
+}
+
 func (c *Config) GetString(name string) string {
 	return c.GetStringOr(name, "")
 }
 
 // Read string property or retun defValue if property is not exists or empty
 func (c *Config) GetStringOr(name string, defValue string) string {
-	if val, ok := c.storage[name]; ok {
+	if val, ok := c.lookup(name); ok {
 		return val
 	}
 	return defValue
 }
 
+// Read string property, returns ErrKeyNotFound if property is not exists
+func (c *Config) GetStringE(name string) (string, error) {
+	if val, ok := c.lookup(name); ok {
+		return val, nil
+	}
+	return ``, c.notFound(name)
+}
+
 // Read integer property. If property is not exists or empty will return 0
 func (c *Config) GetInt(name string) int {
 	return c.GetIntOr(name, 0)
 }
 
-// Read integer property or return defValue if property is not exists or empty
+// Read integer property or return defValue if property is not exists or empty.
+// Values out of the int range, beyond 2147483647 on 32-bit platforms, also
+// return defValue; use GetInt64 or GetUint64 for large IDs and byte counts.
 func (c *Config) GetIntOr(name string, defValue int) int {
-	if val, ok := c.storage[name]; ok {
-		r, e := strconv.Atoi(val)
-		if e != nil {
-			return defValue
-		}
+	v, e := c.GetIntE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read integer property, returns an error if property is not exists, invalid
+// or out of the int range
+func (c *Config) GetIntE(name string) (int, error) {
+	var v int
+	e := c.parseKind(name, KindInt, func(s string) (e error) {
+		v, e = strconv.Atoi(s)
+		return e
+	})
+	return v, e
+}
 
-		return r
+// Read boolean property. If property is not exists or invalid will return
+// false
+func (c *Config) GetBool(name string) bool {
+	return c.GetBoolOr(name, false)
+}
+
+// Read boolean property or return defValue if property is not exists or
+// invalid
+func (c *Config) GetBoolOr(name string, defValue bool) bool {
+	v, e := c.GetBoolE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
 	}
-	return defValue
+	return v
+}
+
+// Read boolean property, returns an error if property is not exists or
+// invalid. Which values are booleans depends on SetCoercion.
+func (c *Config) GetBoolE(name string) (bool, error) {
+	var v bool
+	e := c.parseKind(name, KindBool, func(s string) (e error) {
+		v, e = strconv.ParseBool(s)
+		return e
+	})
+	return v, e
+}
+
+// Return a copy of all properties keyed by their full name
+func (c *Config) GetAll() map[string]string {
+	if c.parent != nil {
+		return c.parent.GetStringMap(c.subPrefix)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	all := copyStorage(c.storage)
+	c.applyEnv(all)
+	return all
+}
+
+// Return all properties as nested maps and arrays. Keys are split on dots
+// and the leading dot of properties declared outside any section is dropped.
+func (c *Config) GetAllNested() map[string]interface{} {
+	return Unflatten(c.GetAll())
 }
@@ -0,0 +1,94 @@
+// Package configtest provides helpers for testing code that uses go-config.
+package configtest
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	config "github.com/budimanlai/go-config"
+)
+
+var update = flag.Bool("configtest.update", false, "rewrite golden files with the current config")
+
+// AssertMatchesGolden compares the nested export of cfg with the JSON golden
+// file and reports every added, removed or changed key. Run the tests with
+// -configtest.update to (re)write the golden file.
+func AssertMatchesGolden(t testing.TB, cfg *config.Config, golden string) bool {
+	t.Helper()
+
+	actual, e := json.MarshalIndent(cfg.GetAllNested(), "", "  ")
+	if e != nil {
+		t.Fatalf("configtest: encode config: %v", e)
+		return false
+	}
+
+	if *update {
+		if e := os.MkdirAll(filepath.Dir(golden), 0o755); e != nil {
+			t.Fatalf("configtest: %v", e)
+			return false
+		}
+		if e := os.WriteFile(golden, append(actual, '\n'), 0o644); e != nil {
+			t.Fatalf("configtest: %v", e)
+			return false
+		}
+		return true
+	}
+
+	raw, e := os.ReadFile(golden)
+	if e != nil {
+		t.Fatalf("configtest: read golden file: %v", e)
+		return false
+	}
+
+	var expected interface{}
+	if e := json.Unmarshal(raw, &expected); e != nil {
+		t.Fatalf("configtest: parse golden file %s: %v", golden, e)
+		return false
+	}
+
+	diff := Diff(config.Flatten(expected), config.Flatten(cfg.GetAllNested()))
+	if len(diff) == 0 {
+		return true
+	}
+	t.Errorf("config does not match %s:\n%s", golden, strings.Join(diff, "\n"))
+	return false
+}
+
+// Diff returns one line per key that differs between expected and actual,
+// sorted by key. Lines start with "-" (missing), "+" (unexpected) or "~" (changed).
+func Diff(expected, actual map[string]string) []string {
+	keys := make(map[string]bool)
+	for k := range expected {
+		keys[k] = true
+	}
+	for k := range actual {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, k := range sorted {
+		exp, inExp := expected[k]
+		act, inAct := actual[k]
+		switch {
+		case !inAct:
+			lines = append(lines, fmt.Sprintf("- %s = %q", k, exp))
+		case !inExp:
+			lines = append(lines, fmt.Sprintf("+ %s = %q", k, act))
+		case exp != act:
+			lines = append(lines, fmt.Sprintf("~ %s: %q => %q", k, exp, act))
+		}
+	}
+	return lines
+}
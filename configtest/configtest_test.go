@@ -0,0 +1,122 @@
+package configtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	config "github.com/budimanlai/go-config"
+)
+
+// recorder is a testing.TB keeping the failures reported to it
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func newConfig(props map[string]string) *config.Config {
+	c := &config.Config{}
+	c.LoadMap(props, config.MapOptions{})
+	return c
+}
+
+func writeGolden(t *testing.T, data string) string {
+	t.Helper()
+
+	golden := filepath.Join(t.TempDir(), `app.golden.json`)
+	if e := os.WriteFile(golden, []byte(data), 0o644); e != nil {
+		t.Fatal(e)
+	}
+	return golden
+}
+
+func TestAssertMatchesGolden(t *testing.T) {
+	golden := writeGolden(t, `{"db": {"host": "localhost", "port": "5432"}, "debug": "true"}`)
+	c := newConfig(map[string]string{`db.host`: `localhost`, `db.port`: `5432`, `debug`: `true`})
+
+	r := &recorder{TB: t}
+	if !AssertMatchesGolden(r, c, golden) || len(r.errors) != 0 {
+		t.Errorf(`matching config reported %v`, r.errors)
+	}
+}
+
+func TestAssertMatchesGoldenMismatch(t *testing.T) {
+	golden := writeGolden(t, `{"db": {"host": "localhost", "port": "5432"}, "debug": "true"}`)
+	c := newConfig(map[string]string{`db.host`: `db.internal`, `db.port`: `5432`, `db.user`: `app`})
+
+	r := &recorder{TB: t}
+	if AssertMatchesGolden(r, c, golden) || r.fatal || len(r.errors) != 1 {
+		t.Fatalf(`mismatch reported %v, fatal %v`, r.errors, r.fatal)
+	}
+
+	want := "config does not match " + golden + ":\n" +
+		"~ db.host: \"localhost\" => \"db.internal\"\n" +
+		"+ db.user = \"app\"\n" +
+		"- debug = \"true\""
+	if r.errors[0] != want {
+		t.Errorf("reported\n%s\nwant\n%s", r.errors[0], want)
+	}
+}
+
+func TestAssertMatchesGoldenMissingFile(t *testing.T) {
+	c := newConfig(map[string]string{`a`: `1`})
+
+	r := &recorder{TB: t}
+	if AssertMatchesGolden(r, c, filepath.Join(t.TempDir(), `missing.json`)) || !r.fatal {
+		t.Errorf(`missing golden file reported %v, fatal %v`, r.errors, r.fatal)
+	}
+}
+
+func TestAssertMatchesGoldenUpdate(t *testing.T) {
+	*update = true
+	defer func() { *update = false }()
+
+	golden := filepath.Join(t.TempDir(), `testdata`, `app.golden.json`)
+	c := newConfig(map[string]string{`db.host`: `localhost`, `servers.0`: `a`})
+
+	r := &recorder{TB: t}
+	if !AssertMatchesGolden(r, c, golden) || len(r.errors) != 0 {
+		t.Fatalf(`update reported %v`, r.errors)
+	}
+	data, e := os.ReadFile(golden)
+	if e != nil {
+		t.Fatal(e)
+	}
+	want := "{\n  \"db\": {\n    \"host\": \"localhost\"\n  },\n  \"servers\": [\n    \"a\"\n  ]\n}\n"
+	if string(data) != want {
+		t.Errorf("golden file\n%s\nwant\n%s", data, want)
+	}
+
+	// the rewritten file matches once the flag is off again
+	*update = false
+	c.Set(`db.host`, `db.internal`)
+	r = &recorder{TB: t}
+	if AssertMatchesGolden(r, c, golden) || !strings.Contains(strings.Join(r.errors, "\n"), `~ db.host: "localhost" => "db.internal"`) {
+		t.Errorf(`changed config reported %v`, r.errors)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := Diff(map[string]string{`a`: `1`, `b`: `2`}, map[string]string{`b`: `3`, `c`: `4`})
+	want := []string{`- a = "1"`, `~ b: "2" => "3"`, `+ c = "4"`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf(`Diff = %q, want %q`, got, want)
+	}
+	if got := Diff(map[string]string{`a`: `1`}, map[string]string{`a`: `1`}); len(got) != 0 {
+		t.Errorf(`Diff of equal maps = %q`, got)
+	}
+}
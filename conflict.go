@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ConflictFunc decides the value of key when a later source (newSrc) defines
+// a key an earlier one (oldSrc) already did. It returns the value to keep,
+// usually oldVal or newVal, or an error that fails the load.
+type ConflictFunc func(key, oldVal, newVal, oldSrc, newSrc string) (string, error)
+
+// Call fn whenever two files or sources define the same property, instead of
+// letting the later one win, e.g. to enforce that secrets only come from
+// Vault. Sources are named like in SourceStatus, files by their path. A nil
+// fn restores the default.
+func (c *Config) SetConflictResolver(fn ConflictFunc) {
+	if c.parent != nil {
+		c.parent.SetConflictResolver(fn)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conflict = fn
+}
+
+// resolveConflicts runs fn for every property of layer from src already set
+// in tmp. It returns layer, or a copy when fn changed a value.
+func resolveConflicts(fn ConflictFunc, tmp *Config, layer map[string]string, src string) (map[string]string, error) {
+	var keys []string
+	for k := range layer {
+		if _, ok := tmp.storage[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	copied := false
+	for _, k := range keys {
+		val, e := fn(k, tmp.storage[k], layer[k], tmp.origins[k], src)
+		if e != nil {
+			return nil, fmt.Errorf(`%s: %s: %v`, src, k, e)
+		}
+		if val == layer[k] {
+			continue
+		}
+		if !copied {
+			layer = copyStorage(layer)
+			copied = true
+		}
+		layer[k] = val
+	}
+	return layer, nil
+}
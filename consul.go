@@ -0,0 +1,243 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsulSink writes properties to the Consul KV store. Property
+// database.host is stored under Prefix + "database/host".
+type ConsulSink struct {
+	Address string
+	Prefix  string
+	Token   string
+	Client  *http.Client
+}
+
+// Create sink for the Consul agent at address (e.g. http://127.0.0.1:8500)
+func NewConsulSink(address string, prefix string) *ConsulSink {
+	return &ConsulSink{
+		Address: strings.TrimRight(address, "/"),
+		Prefix:  prefix,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Put stores value only if the key's ModifyIndex still equals version
+// (check-and-set). Version 0 only creates a key that does not exist yet.
+// The returned version is the ModifyIndex of the write.
+func (s *ConsulSink) Put(key string, value string, version int64) (int64, error) {
+	versions, e := s.PutAll([]RemotePut{{Key: key, Value: value, Version: version}})
+	if e != nil {
+		return 0, e
+	}
+	return versions[0], nil
+}
+
+// PutAll writes puts with the check-and-set operations of one /v1/txn
+// transaction, which fails with ErrConflict when any key differs from its
+// version. Consul accepts at most 64 operations per transaction.
+func (s *ConsulSink) PutAll(puts []RemotePut) ([]int64, error) {
+	type kvOp struct {
+		Verb  string
+		Key   string
+		Value []byte
+		Index int64
+	}
+	ops := make([]map[string]kvOp, len(puts))
+	for i, p := range puts {
+		ops[i] = map[string]kvOp{"KV": {Verb: "cas", Key: remoteKey(s.Prefix, p.Key), Value: []byte(p.Value), Index: p.Version}}
+	}
+	body, e := json.Marshal(ops)
+	if e != nil {
+		return nil, e
+	}
+
+	req, e := http.NewRequest(http.MethodPut, s.Address+"/v1/txn", bytes.NewReader(body))
+	if e != nil {
+		return nil, e
+	}
+	if s.Token != `` {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+	r, e := s.Client.Do(req)
+	if e != nil {
+		return nil, e
+	}
+	defer r.Body.Close()
+
+	data, e := io.ReadAll(r.Body)
+	if e != nil {
+		return nil, e
+	}
+	if r.StatusCode == http.StatusConflict {
+		// a failed check-and-set rolls back the whole transaction
+		return nil, ErrConflict
+	}
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(`consul PUT /v1/txn: %s: %s`, r.Status, bytes.TrimSpace(data))
+	}
+
+	var resp struct {
+		Results []struct {
+			KV struct {
+				ModifyIndex int64
+			}
+		}
+	}
+	if e := json.Unmarshal(data, &resp); e != nil {
+		return nil, e
+	}
+	if len(resp.Results) != len(puts) {
+		return nil, fmt.Errorf(`consul: txn returned %d results for %d writes`, len(resp.Results), len(puts))
+	}
+	versions := make([]int64, len(puts))
+	for i, res := range resp.Results {
+		versions[i] = res.KV.ModifyIndex
+	}
+	return versions, nil
+}
+
+// escapePath escapes every segment of a slash separated key
+func escapePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// ConsulSource reads properties from the Consul KV store. Key Prefix +
+// "database/host" becomes database.host. Changes are watched with blocking
+// queries.
+type ConsulSource struct {
+	Address string
+	Prefix  string
+	Token   string
+	Client  *http.Client
+
+	mu        sync.Mutex
+	revisions map[string]int64
+}
+
+// Create source for the keys under prefix (e.g. "myapp/") on the Consul agent
+// at address (e.g. http://127.0.0.1:8500)
+func NewConsulSource(address string, prefix string) *ConsulSource {
+	return &ConsulSource{
+		Address: strings.TrimRight(address, "/"),
+		Prefix:  prefix,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Load returns every key under Prefix
+func (s *ConsulSource) Load(ctx context.Context) (map[string]string, error) {
+	props, revisions, _, e := s.list(ctx, s.Client, ``)
+	if e != nil {
+		return nil, e
+	}
+
+	s.mu.Lock()
+	s.revisions = revisions
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Revisions returns the ModifyIndex of every property of the last Load
+func (s *ConsulSource) Revisions() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revisions := make(map[string]int64, len(s.revisions))
+	for k, v := range s.revisions {
+		revisions[k] = v
+	}
+	return revisions
+}
+
+// Watch calls notify whenever the index of the keys under Prefix changes
+func (s *ConsulSource) Watch(ctx context.Context, notify func()) error {
+	// blocking queries wait up to 5 minutes, so the client timeout must not
+	// apply
+	client := *s.Client
+	client.Timeout = 0
+
+	_, _, index, e := s.list(ctx, &client, ``)
+	if e != nil {
+		return e
+	}
+	for {
+		_, _, next, e := s.list(ctx, &client, index)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if e != nil {
+			return e
+		}
+		if next != index {
+			index = next
+			notify()
+		}
+	}
+}
+
+// list reads the keys under Prefix, blocking until the index differs from
+// index when it is not blank. It returns the properties, their ModifyIndex
+// and the new index.
+func (s *ConsulSource) list(ctx context.Context, client *http.Client, index string) (map[string]string, map[string]int64, string, error) {
+	u := s.Address + "/v1/kv/" + escapePath(s.Prefix) + "?recurse=true"
+	if index != `` {
+		u += "&wait=5m&index=" + url.QueryEscape(index)
+	}
+	req, e := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if e != nil {
+		return nil, nil, ``, e
+	}
+	if s.Token != `` {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+
+	r, e := client.Do(req)
+	if e != nil {
+		return nil, nil, ``, e
+	}
+	defer r.Body.Close()
+
+	props := make(map[string]string)
+	revisions := make(map[string]int64)
+	next := r.Header.Get("X-Consul-Index")
+	if r.StatusCode == http.StatusNotFound {
+		return props, revisions, next, nil
+	}
+	if r.StatusCode != http.StatusOK {
+		return nil, nil, ``, fmt.Errorf(`consul GET %s: %s`, s.Prefix, r.Status)
+	}
+
+	var entries []struct {
+		Key         string
+		Value       []byte
+		ModifyIndex int64
+	}
+	if e := json.NewDecoder(r.Body).Decode(&entries); e != nil {
+		return nil, nil, ``, e
+	}
+	for _, kv := range entries {
+		if strings.HasSuffix(kv.Key, "/") {
+			continue // folder
+		}
+		name := propertyName(strings.TrimPrefix(kv.Key, s.Prefix), "/")
+		if name != `` {
+			props[name] = string(kv.Value)
+			revisions[name] = kv.ModifyIndex
+		}
+	}
+	return props, revisions, next, nil
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var csvHeader = []string{`key`, `value`, `source`, `comment`}
+
+// Write every property as CSV with the columns key, value, source and
+// comment (see Describe), sorted by key, for review in a spreadsheet. Secret
+// values are written as ******.
+func (c *Config) ExportCSV(w io.Writer) error {
+	all := c.GetAll()
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cw := csv.NewWriter(w)
+	if e := cw.Write(csvHeader); e != nil {
+		return e
+	}
+
+	root, prefix := c.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
+	for _, k := range keys {
+		val := all[k]
+		if root.secrets[prefix+k] {
+			val = secretMask
+		}
+		if e := cw.Write([]string{k, val, root.origin(prefix + k), root.description(prefix + k)}); e != nil {
+			return e
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Read a CSV written by ExportCSV, usually after editing, and apply the
+// changed values as a layer like LoadMap. Only the key and value columns are
+// required. Rows of secrets left as ****** and unchanged rows are skipped.
+// Nothing is applied when a key is empty or repeated, or a value does not
+// match its declared type.
+func (c *Config) LoadCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, e := cr.Read()
+	if e == io.EOF {
+		return errors.New(`csv: missing header`)
+	}
+	if e != nil {
+		return fmt.Errorf(`csv: %v`, e)
+	}
+	keyCol, valCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case `key`:
+			keyCol = i
+		case `value`:
+			valCol = i
+		}
+	}
+	if keyCol < 0 || valCol < 0 {
+		return errors.New(`csv: header needs key and value columns`)
+	}
+
+	rows := make(map[string]string)
+	for {
+		rec, e := cr.Read()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return fmt.Errorf(`csv: %v`, e)
+		}
+		line, _ := cr.FieldPos(0)
+		if keyCol >= len(rec) || valCol >= len(rec) {
+			return fmt.Errorf(`csv: line %d: missing columns`, line)
+		}
+
+		key := normalizeKey(strings.TrimSpace(rec[keyCol]))
+		if key == `` {
+			return fmt.Errorf(`csv: line %d: empty key`, line)
+		}
+		if _, ok := rows[key]; ok {
+			return fmt.Errorf(`csv: line %d: duplicate key %q`, line, key)
+		}
+		rows[key] = rec[valCol]
+	}
+
+	if c.parent != nil {
+		return c.parent.loadCSVRows(c.subKeys(rows))
+	}
+	return c.loadCSVRows(rows)
+}
+
+// loadCSVRows applies the key and value of the rows read by LoadCSV
+func (c *Config) loadCSVRows(rows map[string]string) error {
+	layer := mapLayer{storage: make(map[string]string, len(rows))}
+	var invalid []TypeGuess
+	c.mu.RLock()
+	for k, v := range rows {
+		if cur, ok := c.storage[k]; ok && (cur == v || c.secrets[k] && v == secretMask) {
+			continue
+		}
+		if _, g := c.typedValue(k, v); g.Invalid {
+			invalid = append(invalid, g)
+		}
+		layer.storage[k] = v
+	}
+	c.mu.RUnlock()
+
+	if len(invalid) > 0 {
+		sort.Slice(invalid, func(i, j int) bool { return invalid[i].Key < invalid[j].Key })
+		return fmt.Errorf(`csv: %s: %v`, invalid[0].Key, c.invalidType(invalid[0]))
+	}
+	if len(layer.storage) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.layers = append(c.layers, layer)
+	if c.storage == nil {
+		c.storage = make(map[string]string)
+	}
+	mergeLayer(c.storage, layer.storage, c.arrayMerge)
+	c.applyOverrides(c.storage)
+	return nil
+}
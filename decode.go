@@ -0,0 +1,292 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Decode all properties into the struct pointed by v. Fields are matched by
+// the `config` tag, then the `json` tag, then case-insensitively by name.
+// Nested structs map to sections, slices to indexed keys (name.0, name.1, ...).
+func (c *Config) MapToStructNested(v interface{}) error {
+	return c.scrub(c.decoder().decodeInto(c.GetAllNested(), v))
+}
+
+// Decode the properties under key (e.g. "cache.redis") into the struct
+// pointed by v like MapToStructNested, with names relative to key. Returns
+// ErrKeyNotFound if no property is under key.
+func (c *Config) UnmarshalKey(key string, v interface{}) error {
+	nested := c.GetStringMapNested(key)
+	if len(nested) == 0 {
+		return c.notFound(key)
+	}
+	return c.scrub(c.decoder().decodeInto(nested, v))
+}
+
+// Decode the array property name (name.0, name.1, ...) into the slice
+// pointed by v. Elements keep their nested objects, so servers.0.tls.cert
+// fills the TLS field of a []Server element.
+func (c *Config) GetArrayToStruct(name string, v interface{}) error {
+	elems := arrayify(c.GetStringMapNested(name))
+	if m, ok := elems.(map[string]interface{}); ok {
+		if len(m) > 0 {
+			return fmt.Errorf(`config: %s is not an array`, normalizeKey(name))
+		}
+		elems = []interface{}{}
+	}
+	return c.scrub(c.decoder().decodeInto(elems, v))
+}
+
+// decoder carries the settings of a Config used while decoding
+type decoder struct {
+	numbers *numberFormat
+	coerce  CoerceFunc
+}
+
+func (c *Config) decoder() *decoder {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return &decoder{numbers: c.numbers, coerce: c.coercion()}
+}
+
+func (d *decoder) decodeInto(in interface{}, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf(`config: decode target must be a non-nil pointer, got %T`, v)
+	}
+	return d.decodeValue(``, in, rv.Elem())
+}
+
+func (d *decoder) decodeValue(path string, in interface{}, out reflect.Value) error {
+	if in == nil {
+		return nil
+	}
+
+	if out.Kind() == reflect.Ptr {
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		return d.decodeValue(path, in, out.Elem())
+	}
+
+	if s, ok := in.(string); ok && out.CanAddr() && out.Addr().Type().Implements(textUnmarshalerType) {
+		if e := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); e != nil {
+			return decodeError(path, e)
+		}
+		return nil
+	}
+
+	switch out.Kind() {
+	case reflect.Interface:
+		out.Set(reflect.ValueOf(in))
+		return nil
+	case reflect.Struct:
+		return d.decodeStruct(path, in, out)
+	case reflect.Map:
+		return d.decodeMap(path, in, out)
+	case reflect.Slice, reflect.Array:
+		return d.decodeSlice(path, in, out)
+	}
+
+	s, ok := in.(string)
+	if !ok {
+		return decodeError(path, fmt.Errorf(`cannot decode %T into %s`, in, out.Type()))
+	}
+	return d.setScalar(path, s, out)
+}
+
+func (d *decoder) setScalar(path string, s string, out reflect.Value) error {
+	if out.Kind() == reflect.String {
+		out.SetString(strings.TrimSpace(s))
+		return nil
+	}
+	if strings.TrimSpace(s) == `` {
+		return nil
+	}
+
+	switch out.Kind() {
+	case reflect.Bool:
+		s, e := d.coerce(path, s, KindBool)
+		if e != nil {
+			return decodeError(path, e)
+		}
+		b, e := strconv.ParseBool(s)
+		if e != nil {
+			return decodeError(path, e)
+		}
+		out.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if out.Type() == durationType {
+			s, e := d.coerce(path, s, KindDuration)
+			if e != nil {
+				return decodeError(path, e)
+			}
+			dur, e := time.ParseDuration(s)
+			if e != nil {
+				return decodeError(path, e)
+			}
+			out.SetInt(int64(dur))
+			return nil
+		}
+		s, e := d.coerce(path, s, KindInt)
+		if e != nil {
+			return decodeError(path, e)
+		}
+		i, e := strconv.ParseInt(s, 10, out.Type().Bits())
+		if e != nil {
+			return decodeError(path, e)
+		}
+		out.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s, e := d.coerce(path, s, KindInt)
+		if e != nil {
+			return decodeError(path, e)
+		}
+		u, e := strconv.ParseUint(s, 10, out.Type().Bits())
+		if e != nil {
+			return decodeError(path, e)
+		}
+		out.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		s, e := d.coerce(path, s, KindFloat)
+		if e != nil {
+			return decodeError(path, e)
+		}
+		f, e := d.numbers.parseFloat(s, out.Type().Bits())
+		if e != nil {
+			return decodeError(path, e)
+		}
+		out.SetFloat(f)
+	default:
+		return decodeError(path, fmt.Errorf(`unsupported type %s`, out.Type()))
+	}
+	return nil
+}
+
+func (d *decoder) decodeStruct(path string, in interface{}, out reflect.Value) error {
+	m, ok := asMap(in)
+	if !ok {
+		return decodeError(path, fmt.Errorf(`cannot decode %T into %s`, in, out.Type()))
+	}
+
+	for _, field := range structFields(out.Type()) {
+		if field.inline {
+			if e := d.decodeValue(path, m, out.Field(field.index)); e != nil {
+				return e
+			}
+			continue
+		}
+
+		val, found := lookupField(m, field.name)
+		if !found {
+			continue
+		}
+		if e := d.decodeValue(joinKey(path, field.name), val, out.Field(field.index)); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeMap(path string, in interface{}, out reflect.Value) error {
+	m, ok := asMap(in)
+	if !ok {
+		return decodeError(path, fmt.Errorf(`cannot decode %T into %s`, in, out.Type()))
+	}
+	if out.Type().Key().Kind() != reflect.String {
+		return decodeError(path, fmt.Errorf(`unsupported map key type %s`, out.Type().Key()))
+	}
+
+	if out.IsNil() {
+		out.Set(reflect.MakeMapWithSize(out.Type(), len(m)))
+	}
+	for k, val := range m {
+		elem := reflect.New(out.Type().Elem()).Elem()
+		if e := d.decodeValue(joinKey(path, k), val, elem); e != nil {
+			return e
+		}
+		out.SetMapIndex(reflect.ValueOf(k).Convert(out.Type().Key()), elem)
+	}
+	return nil
+}
+
+func (d *decoder) decodeSlice(path string, in interface{}, out reflect.Value) error {
+	arr, ok := in.([]interface{})
+	if !ok {
+		return decodeError(path, fmt.Errorf(`cannot decode %T into %s`, in, out.Type()))
+	}
+
+	if out.Kind() == reflect.Slice {
+		out.Set(reflect.MakeSlice(out.Type(), len(arr), len(arr)))
+	}
+	for i, val := range arr {
+		if i >= out.Len() {
+			break
+		}
+		if e := d.decodeValue(joinKey(path, strconv.Itoa(i)), val, out.Index(i)); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// asMap accepts objects as well as arrays, so that a map field can be filled
+// from indexed keys.
+func asMap(in interface{}) (map[string]interface{}, bool) {
+	switch val := in.(type) {
+	case map[string]interface{}:
+		return val, true
+	case []interface{}:
+		m := make(map[string]interface{}, len(val))
+		for i, v := range val {
+			m[strconv.Itoa(i)] = v
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+func fieldName(field reflect.StructField) (string, bool) {
+	for _, tag := range []string{`config`, `json`} {
+		if v, ok := field.Tag.Lookup(tag); ok {
+			name := strings.Split(v, ",")[0]
+			if name != `` {
+				return name, true
+			}
+		}
+	}
+	return field.Name, false
+}
+
+func lookupField(m map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func decodeError(path string, e error) error {
+	return fmt.Errorf(`config: %s: %v`, path, e)
+}
@@ -0,0 +1,45 @@
+package config
+
+import "strings"
+
+// Return the description of property or section name, taken from the
+// comment lines right above it or the comment after its value in INI and
+// JSONC files. Blank when it has no comment.
+func (c *Config) Describe(name string) string {
+	if c.parent != nil {
+		return c.parent.Describe(c.subPrefix + normalizeKey(name))
+	}
+
+	name = normalizeKey(name)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.description(name)
+}
+
+// description is Describe for a normalized name. Caller must hold c.mu.
+func (c *Config) description(name string) string {
+	if d, ok := c.descriptions[name]; ok {
+		return d
+	}
+	return c.descriptions["."+name] // INI property outside any section
+}
+
+// describeKey stores the description of key read from the comment lines
+// above it and its inline comment. A later file describing the same key
+// replaces the description.
+func (c *Config) describeKey(key string, above []string, inline string) {
+	if key == `` || (len(above) == 0 && inline == ``) {
+		return
+	}
+
+	text := strings.Join(above, "\n")
+	if inline != `` {
+		text = strings.TrimSpace(text + "\n" + inline)
+	}
+	if c.descriptions == nil {
+		c.descriptions = make(map[string]string)
+	}
+	c.descriptions[key] = text
+}
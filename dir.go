@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+)
+
+// Read every file in dir matching pattern (e.g. "*.conf") in lexical order,
+// merged after the files already opened. The directory is listed again on
+// every Reload, and Watch also reloads when files are added or removed.
+func (c *Config) OpenDir(dir string, pattern string) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	if pattern == `` {
+		pattern = `*`
+	}
+	if _, e := filepath.Match(pattern, ``); e != nil {
+		return e
+	}
+	if e := c.checkNotClosed(); e != nil {
+		return e
+	}
+
+	c.mu.RLock()
+	fsys := c.filesystem()
+	c.mu.RUnlock()
+
+	st, e := fsys.Stat(dir)
+	if e != nil {
+		return e
+	}
+	if !st.IsDir() {
+		return errors.New(dir + ` is not a directory`)
+	}
+
+	glob := filepath.Join(dir, pattern)
+
+	c.mu.Lock()
+	if c.dirSources == nil {
+		c.dirSources = make(map[string]string)
+	}
+	c.dirSources[glob] = dir
+	c.sources = append(append([]string(nil), c.sources...), glob)
+	c.mu.Unlock()
+
+	_, e = c.load()
+	if e == nil {
+		c.rewatch()
+	}
+	return e
+}
+
+// sourceFiles returns the files of a source, listing the directory of
+// sources added with OpenDir
+func sourceFiles(fsys FS, source string, dirSources map[string]string) []string {
+	if _, ok := dirSources[source]; !ok {
+		return []string{source}
+	}
+
+	matches, _ := fsys.Glob(source)
+	sort.Strings(matches)
+
+	files := matches[:0]
+	for _, m := range matches {
+		if st, e := fsys.Stat(m); e == nil && !st.IsDir() {
+			files = append(files, m)
+		}
+	}
+	return files
+}
+
+// watchPaths returns the files read by the last load and the directories of
+// OpenDir sources, leaving out reader sources. Caller must hold c.mu.
+func (c *Config) watchPaths() []string {
+	var paths []string
+	for _, name := range c.file {
+		if _, ok := c.readers[name]; !ok {
+			paths = append(paths, name)
+		}
+	}
+	for _, dir := range c.dirSources {
+		paths = append(paths, dir)
+	}
+	return paths
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Limit the length of values in human-facing output (the change feed and
+// SelfTest or Validate problems) to n bytes. Longer values are cut and end
+// with "…(+N bytes)". Get and GetAll always return the full value. Zero, the
+// default, disables the limit.
+func (c *Config) SetMaxValueLength(n int) {
+	if c.parent != nil {
+		c.parent.SetMaxValueLength(n)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n < 0 {
+		n = 0
+	}
+	c.maxValueLen = n
+}
+
+// Return the value of property as it is shown in logs: masked when secret,
+// otherwise cut to the length set with SetMaxValueLength
+func (c *Config) DisplayValue(name string) string {
+	if c.parent != nil {
+		return c.parent.DisplayValue(c.subPrefix + normalizeKey(name))
+	}
+
+	name = normalizeKey(name)
+	val, _ := c.lookup(name)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.displayValue(name, val)
+}
+
+// displayValue masks or truncates val of property name. Caller must hold
+// c.mu.
+func (c *Config) displayValue(name string, val string) string {
+	if c.secrets[name] {
+		return maskValue(val)
+	}
+	return truncateValue(val, c.maxValueLen)
+}
+
+// truncateValue cuts val to at most max bytes, on a rune boundary, and
+// appends the number of bytes removed
+func truncateValue(val string, max int) string {
+	if max <= 0 || len(val) <= max {
+		return val
+	}
+
+	cut := max
+	for cut > 0 && !utf8.RuneStart(val[cut]) {
+		cut--
+	}
+	return val[:cut] + `…(+` + strconv.Itoa(len(val)-cut) + ` bytes)`
+}
+
+// invalidType describes a value that does not match its declared kind
+func (c *Config) invalidType(g TypeGuess) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return fmt.Errorf(`%q is not a valid %s`, c.displayValue(g.Key, g.Value), g.Kind)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// KeyDoc documents one property for DocsHandler
+type KeyDoc struct {
+	TypeGuess
+	Default     string // Namespace.SetDefault value, masked for secrets
+	Source      string // as reported by Origin
+	Description string // as reported by Describe
+}
+
+// Return the documentation of every property, sorted by key: its declared
+// or guessed type, default, current value (masked for secrets), source and
+// description
+func (c *Config) Docs() []KeyDoc {
+	guesses := c.ExplainTypes()
+	root, prefix := c.root()
+
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
+	docs := make([]KeyDoc, len(guesses))
+	for i, g := range guesses {
+		key := prefix + g.Key
+		docs[i] = KeyDoc{
+			TypeGuess:   g,
+			Default:     root.defaults[key],
+			Source:      root.origin(key),
+			Description: root.description(key),
+		}
+		if root.secrets[key] && docs[i].Default != `` {
+			docs[i].Default = secretMask
+		}
+	}
+	return docs
+}
+
+var docsTemplate = template.Must(template.New(`docs`).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Configuration</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+td.invalid { color: #b00; }
+code { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Configuration</h1>
+<table>
+<tr><th>Key</th><th>Type</th><th>Default</th><th>Value</th><th>Source</th><th>Description</th></tr>
+{{range .}}<tr>
+<td><code>{{.Key}}</code></td>
+<td{{if .Invalid}} class="invalid"{{end}}>{{.Kind}}{{if not .Declared}} (guessed){{end}}{{if .Invalid}} (invalid){{end}}</td>
+<td><code>{{.Default}}</code></td>
+<td><code>{{.Value}}</code></td>
+<td>{{.Source}}</td>
+<td>{{.Description}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Return a handler serving Docs as an HTML page, e.g. mounted with
+// mux.Handle("/config/docs", c.DocsHandler()). The page is built on every
+// request so it always matches the running config.
+func (c *Config) DocsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set(`Allow`, `GET, HEAD`)
+			http.Error(w, `method not allowed`, http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set(`Content-Type`, `text/html; charset=utf-8`)
+		if e := docsTemplate.Execute(w, c.Docs()); e != nil {
+			fmt.Println(`Write config docs failed:`, e)
+		}
+	})
+}
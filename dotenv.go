@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Convert an environment variable name into a property name,
+// e.g. DATABASE_HOST becomes database.host
+func DefaultEnvKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "."))
+}
+
+// Set function used to turn .env variable names into property names.
+// The default is DefaultEnvKey.
+func (c *Config) SetEnvKeyTransformer(fn func(name string) string) {
+	if c.parent != nil {
+		c.parent.SetEnvKeyTransformer(fn)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.envKey = fn
+}
+
+func isDotenv(filename string) bool {
+	base := filepath.Base(filename)
+	return filepath.Ext(base) == ".env" || base == ".env" || strings.HasPrefix(base, ".env.")
+}
+
+// Read .env file with KEY=VALUE lines. Lines may start with `export`, values
+// may be single quoted (literal) or double quoted (escapes, multiple lines).
+func (f *File) ReadEnv(c *Config) error {
+	fi, e := f.open(c)
+	if e != nil {
+		return e
+	}
+	defer fi.Close()
+
+	fmt.Println(`Read config:`, f.filename)
+	c.file = append(c.file, f.filename)
+
+	transform := c.envKey
+	if transform == nil {
+		transform = DefaultEnvKey
+	}
+
+	scanner := bufio.NewScanner(fi)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == `` || strings.HasPrefix(line, `#`) {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, `export `))
+
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return fmt.Errorf(`%s:%d: expected KEY=VALUE`, f.filename, lineNo)
+		}
+		key := strings.TrimSpace(line[:eq])
+		val := strings.TrimSpace(line[eq+1:])
+
+		switch {
+		case strings.HasPrefix(val, `'`):
+			end := strings.IndexByte(val[1:], '\'')
+			if end < 0 {
+				return fmt.Errorf(`%s:%d: unterminated quote`, f.filename, lineNo)
+			}
+			val = val[1 : end+1]
+		case strings.HasPrefix(val, `"`):
+			raw := val[1:]
+			for !closedDoubleQuote(raw) {
+				if !scanner.Scan() {
+					return fmt.Errorf(`%s:%d: unterminated quote`, f.filename, lineNo)
+				}
+				lineNo++
+				raw += "\n" + scanner.Text()
+			}
+			val = unescapeDoubleQuoted(raw)
+		default:
+			if i := strings.Index(val, ` #`); i >= 0 {
+				val = strings.TrimSpace(val[:i])
+			}
+		}
+
+		c.storage[transform(key)] = val
+	}
+	return scanner.Err()
+}
+
+func closedDoubleQuote(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeDoubleQuoted returns the text up to the closing quote of s with
+// backslash escapes replaced
+func unescapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch == '"' {
+			break
+		}
+		if ch == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
@@ -0,0 +1,21 @@
+package config
+
+import "errors"
+
+// ErrEmptyFile is returned by Open and Reload for a config file without any
+// property when SetRequireNonEmpty is enabled
+var ErrEmptyFile = errors.New(`config file has no properties`)
+
+// Fail loading when a config file is empty or has only comments. By default
+// such a file is an empty layer, listed in Summary as EmptyFiles.
+func (c *Config) SetRequireNonEmpty(require bool) {
+	if c.parent != nil {
+		c.parent.SetRequireNonEmpty(require)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requireNonEmpty = require
+}
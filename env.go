@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Read properties from environment variables named PREFIX_KEY, e.g. with
+// BindEnvPrefix("MYAPP") MYAPP_DATABASE_HOST is read as database.host. The
+// environment is checked on every Get and takes precedence over config files
+// and runtime overrides. With several prefixes the last bound one wins.
+func (c *Config) BindEnvPrefix(prefix string) {
+	if c.parent != nil {
+		c.parent.BindEnvPrefix(prefix)
+		return
+	}
+
+	prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, p := range c.envPrefixes {
+		if p == prefix {
+			c.envPrefixes = append(c.envPrefixes[:i:i], c.envPrefixes[i+1:]...)
+			break
+		}
+	}
+	c.envPrefixes = append(c.envPrefixes, prefix)
+}
+
+// Read property name from the environment variable env, e.g.
+// BindEnv("database.password", "DB_PASSWORD"). Like BindEnvPrefix the
+// variable is checked on every Get; a per-key binding wins over prefixes.
+func (c *Config) BindEnv(name string, env string) {
+	if c.parent != nil {
+		c.parent.BindEnv(c.subPrefix+normalizeKey(name), env)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.envBindings == nil {
+		c.envBindings = make(map[string]string)
+	}
+	c.envBindings[normalizeKey(name)] = env
+}
+
+// lookupEnv returns the environment value bound to property name. Caller
+// must hold c.mu.
+func (c *Config) lookupEnv(name string) (string, bool) {
+	if env, ok := c.envBindings[name]; ok {
+		if val, ok := os.LookupEnv(env); ok {
+			return val, true
+		}
+	}
+	if len(c.envPrefixes) == 0 {
+		return ``, false
+	}
+
+	suffix := envName(name)
+	for i := len(c.envPrefixes) - 1; i >= 0; i-- {
+		if val, ok := os.LookupEnv(c.envPrefixes[i] + "_" + suffix); ok {
+			return val, true
+		}
+	}
+	return ``, false
+}
+
+// applyEnv copies the bound environment variables into storage. Caller must
+// hold c.mu.
+func (c *Config) applyEnv(storage map[string]string) {
+	for key, val := range c.envValues(storage) {
+		storage[key] = val
+	}
+}
+
+// envValues returns the properties read from bound environment variables.
+// A prefixed variable maps to every key of storage Get reads it for, so
+// MYAPP_LOG_OUTPUT_FILE sets log.output_file, and to DefaultEnvKey of its
+// name when there is none. Caller must hold c.mu.
+func (c *Config) envValues(storage map[string]string) map[string]string {
+	values := make(map[string]string)
+	if len(c.envPrefixes) > 0 {
+		keys := make(map[string][]string, len(storage))
+		for key := range storage {
+			keys[envName(key)] = append(keys[envName(key)], key)
+		}
+
+		for _, prefix := range c.envPrefixes {
+			for _, kv := range os.Environ() {
+				name, val, _ := strings.Cut(kv, "=")
+				if !strings.HasPrefix(name, prefix+"_") || len(name) == len(prefix)+1 {
+					continue
+				}
+
+				suffix := name[len(prefix)+1:]
+				if matched, ok := keys[suffix]; ok {
+					for _, key := range matched {
+						values[key] = val
+					}
+					continue
+				}
+				values[DefaultEnvKey(suffix)] = val
+			}
+		}
+	}
+
+	for name, env := range c.envBindings {
+		if val, ok := os.LookupEnv(env); ok {
+			values[name] = val
+		}
+	}
+	return values
+}
+
+// envName turns a property name into the environment variable suffix,
+// e.g. database.host becomes DATABASE_HOST
+func envName(name string) string {
+	parts := SplitKey(strings.TrimPrefix(name, "."))
+	return strings.ToUpper(strings.ReplaceAll(strings.Join(parts, "_"), "-", "_"))
+}
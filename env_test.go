@@ -0,0 +1,46 @@
+package config
+
+import "testing"
+
+func TestEnvKeyWithUnderscore(t *testing.T) {
+	quietStdout(t)
+
+	c := &Config{}
+	if e := c.LoadString("[log]\noutput_file = app.log\nlevel = info\n", `ini`); e != nil {
+		t.Fatal(e)
+	}
+	c.BindEnvPrefix(`MYAPP`)
+	t.Setenv(`MYAPP_LOG_OUTPUT_FILE`, `/var/log/app.log`)
+
+	if got := c.GetString(`log.output_file`); got != `/var/log/app.log` {
+		t.Errorf(`GetString(log.output_file) = %q`, got)
+	}
+
+	all := c.GetAll()
+	if got := all[`log.output_file`]; got != `/var/log/app.log` {
+		t.Errorf(`GetAll log.output_file = %q`, got)
+	}
+	if _, ok := all[`log.output.file`]; ok {
+		t.Error(`GetAll has log.output.file, a key that was never declared`)
+	}
+	if s := c.Summary(); s.EnvOverrides != 1 || s.Keys != 2 {
+		t.Errorf(`Summary = %+v`, s)
+	}
+
+	var out struct {
+		OutputFile string `config:"output_file"`
+	}
+	if e := c.UnmarshalKey(`log`, &out); e != nil || out.OutputFile != `/var/log/app.log` {
+		t.Errorf(`UnmarshalKey = %v, %+v`, e, out)
+	}
+}
+
+func TestEnvUndeclaredKey(t *testing.T) {
+	c := &Config{}
+	c.BindEnvPrefix(`MYAPP`)
+	t.Setenv(`MYAPP_CACHE_TTL`, `30`)
+
+	if got := c.GetAll()[`cache.ttl`]; got != `30` {
+		t.Errorf(`GetAll cache.ttl = %q, want the DefaultEnvKey fallback`, got)
+	}
+}
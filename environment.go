@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvironmentOptions changes how DetectEnvironment decides the environment
+type EnvironmentOptions struct {
+	// Override wins over every signal when set, e.g. from a command line flag
+	Override string
+	// Vars are the environment variables checked in order, APP_ENV, GO_ENV
+	// and ENV by default
+	Vars []string
+	// Dir is searched for *.local.* and .env.local files, which select the
+	// local environment. The working directory by default.
+	Dir string
+	// Default is returned when no signal is found, development by default
+	Default string
+}
+
+// Names of the environments returned by DetectEnvironment
+const (
+	EnvDevelopment = `development`
+	EnvTest        = `test`
+	EnvStaging     = `staging`
+	EnvProduction  = `production`
+	EnvLocal       = `local`
+)
+
+var envAliases = map[string]string{
+	`dev`:         EnvDevelopment,
+	`develop`:     EnvDevelopment,
+	`development`: EnvDevelopment,
+	`test`:        EnvTest,
+	`testing`:     EnvTest,
+	`qa`:          EnvTest,
+	`stage`:       EnvStaging,
+	`staging`:     EnvStaging,
+	`stg`:         EnvStaging,
+	`prod`:        EnvProduction,
+	`production`:  EnvProduction,
+	`prd`:         EnvProduction,
+	`local`:       EnvLocal,
+}
+
+const k8sNamespaceFile = `/var/run/secrets/kubernetes.io/serviceaccount/namespace`
+
+// Return the environment the program runs in, checking in order:
+// opts.Override, the variables of opts.Vars, the Kubernetes namespace when
+// its last dash separated part names an environment (e.g. shop-prod), and
+// local override files. Known aliases are made canonical, e.g. prod becomes
+// production. The result selects the overlays of OpenProfile.
+func DetectEnvironment(opts EnvironmentOptions) string {
+	if opts.Override != `` {
+		return canonicalEnv(opts.Override)
+	}
+
+	vars := opts.Vars
+	if vars == nil {
+		vars = []string{`APP_ENV`, `GO_ENV`, `ENV`}
+	}
+	for _, name := range vars {
+		if val := strings.TrimSpace(os.Getenv(name)); val != `` {
+			return canonicalEnv(val)
+		}
+	}
+
+	if ns := k8sNamespace(); ns != `` {
+		parts := strings.Split(ns, `-`)
+		if env, ok := envAliases[strings.ToLower(parts[len(parts)-1])]; ok {
+			return env
+		}
+	}
+
+	dir := opts.Dir
+	if dir == `` {
+		dir = `.`
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, `*.local.*`)); len(matches) > 0 {
+		return EnvLocal
+	}
+	if _, e := os.Stat(filepath.Join(dir, `.env.local`)); e == nil {
+		return EnvLocal
+	}
+
+	if opts.Default != `` {
+		return canonicalEnv(opts.Default)
+	}
+	return EnvDevelopment
+}
+
+// canonicalEnv maps an environment alias to its canonical name, unknown
+// names are only lower cased
+func canonicalEnv(env string) string {
+	env = strings.ToLower(strings.TrimSpace(env))
+	if canonical, ok := envAliases[env]; ok {
+		return canonical
+	}
+	return env
+}
+
+// k8sNamespace returns the Kubernetes namespace of the pod, blank outside
+// Kubernetes
+func k8sNamespace() string {
+	if ns := os.Getenv(`POD_NAMESPACE`); ns != `` {
+		return ns
+	}
+	if os.Getenv(`KUBERNETES_SERVICE_HOST`) == `` {
+		return ``
+	}
+	data, e := os.ReadFile(k8sNamespaceFile)
+	if e != nil {
+		return ``
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Read config files followed by their overlays for env, e.g. config.ini then
+// config.production.ini when env is production. Missing overlays are
+// skipped. Use DetectEnvironment for env.
+func (c *Config) OpenProfile(env string, file ...string) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	c.mu.RLock()
+	fsys := c.filesystem()
+	c.mu.RUnlock()
+
+	var files []string
+	for _, name := range file {
+		files = append(files, name)
+		if env == `` {
+			continue
+		}
+
+		ext := filepath.Ext(name)
+		overlay := strings.TrimSuffix(name, ext) + `.` + env + ext
+		if _, e := fsys.Stat(overlay); e == nil {
+			files = append(files, overlay)
+		}
+	}
+	return c.Open(files...)
+}
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EtcdSink writes properties to etcd v3 through its JSON gateway. Property
+// database.host is stored under Prefix + "database/host".
+type EtcdSink struct {
+	Endpoint string
+	Prefix   string
+	Client   *http.Client
+}
+
+// Create sink for the etcd gateway at endpoint (e.g. http://127.0.0.1:2379)
+func NewEtcdSink(endpoint string, prefix string) *EtcdSink {
+	return &EtcdSink{
+		Endpoint: strings.TrimRight(endpoint, "/"),
+		Prefix:   prefix,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Put stores value only if the key's mod_revision still equals version
+// (compare-and-swap). Version 0 only creates a key that does not exist yet.
+// The returned version is the revision of the write.
+func (s *EtcdSink) Put(key string, value string, version int64) (int64, error) {
+	versions, e := s.PutAll([]RemotePut{{Key: key, Value: value, Version: version}})
+	if e != nil {
+		return 0, e
+	}
+	return versions[0], nil
+}
+
+// PutAll writes puts in one etcd transaction that fails with ErrConflict
+// when any key differs from its version
+func (s *EtcdSink) PutAll(puts []RemotePut) ([]int64, error) {
+	compare := make([]map[string]string, len(puts))
+	success := make([]map[string]interface{}, len(puts))
+	for i, p := range puts {
+		key := base64.StdEncoding.EncodeToString([]byte(remoteKey(s.Prefix, p.Key)))
+		if p.Version == 0 {
+			// version counts the writes since creation, 0 when missing
+			compare[i] = map[string]string{"key": key, "result": "EQUAL", "target": "VERSION", "version": "0"}
+		} else {
+			compare[i] = map[string]string{"key": key, "result": "EQUAL", "target": "MOD", "mod_revision": strconv.FormatInt(p.Version, 10)}
+		}
+		success[i] = map[string]interface{}{"request_put": map[string]string{
+			"key":   key,
+			"value": base64.StdEncoding.EncodeToString([]byte(p.Value)),
+		}}
+	}
+
+	var resp struct {
+		Header struct {
+			Revision string `json:"revision"`
+		} `json:"header"`
+		Succeeded bool `json:"succeeded"`
+	}
+	txn := map[string]interface{}{"compare": compare, "success": success}
+	if e := s.call("/v3/kv/txn", txn, &resp); e != nil {
+		return nil, e
+	}
+	if !resp.Succeeded {
+		return nil, ErrConflict
+	}
+
+	rev, e := strconv.ParseInt(resp.Header.Revision, 10, 64)
+	if e != nil {
+		return nil, e
+	}
+	versions := make([]int64, len(puts))
+	for i := range versions {
+		versions[i] = rev
+	}
+	return versions, nil
+}
+
+func (s *EtcdSink) call(path string, req interface{}, resp interface{}) error {
+	return s.callContext(context.Background(), path, req, resp)
+}
+
+// remoteKey maps property database.host to prefix + "database/host"
+func remoteKey(prefix string, key string) string {
+	return prefix + strings.Join(SplitKey(key), "/")
+}
+
+// EtcdSource reads the properties stored under Prefix from etcd v3 through
+// its JSON gateway. Key Prefix + "database/host" becomes database.host.
+type EtcdSource struct {
+	Endpoints []string
+	Prefix    string
+	Client    *http.Client
+
+	mu        sync.Mutex
+	revisions map[string]int64
+}
+
+// Create source for the keys under prefix (e.g. "/myapp/") on the first
+// reachable etcd gateway of endpoints (e.g. http://127.0.0.1:2379). Add it
+// with Config.AddSource; etcd watch events trigger a Reload.
+func NewEtcdSource(endpoints []string, prefix string) *EtcdSource {
+	trimmed := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		trimmed[i] = strings.TrimRight(endpoint, "/")
+	}
+	return &EtcdSource{
+		Endpoints: trimmed,
+		Prefix:    prefix,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Load returns every key under Prefix
+func (s *EtcdSource) Load(ctx context.Context) (map[string]string, error) {
+	req := map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(s.Prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixEnd(s.Prefix)),
+	}
+
+	var resp struct {
+		Kvs []struct {
+			Key         string `json:"key"`
+			Value       string `json:"value"`
+			ModRevision string `json:"mod_revision"`
+		} `json:"kvs"`
+	}
+
+	e := errors.New(`etcd: no endpoints`)
+	for _, endpoint := range s.Endpoints {
+		sink := &EtcdSink{Endpoint: endpoint, Client: s.Client}
+		if e = sink.callContext(ctx, "/v3/kv/range", req, &resp); e == nil {
+			break
+		}
+	}
+	if e != nil {
+		return nil, e
+	}
+
+	props := make(map[string]string, len(resp.Kvs))
+	revisions := make(map[string]int64, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		key, e := base64.StdEncoding.DecodeString(kv.Key)
+		if e != nil {
+			return nil, e
+		}
+		value, e := base64.StdEncoding.DecodeString(kv.Value)
+		if e != nil {
+			return nil, e
+		}
+
+		name := propertyName(strings.TrimPrefix(string(key), s.Prefix), "/")
+		if name != `` {
+			props[name] = string(value)
+			revisions[name], _ = strconv.ParseInt(kv.ModRevision, 10, 64)
+		}
+	}
+
+	s.mu.Lock()
+	s.revisions = revisions
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Revisions returns the mod_revision of every property of the last Load
+func (s *EtcdSource) Revisions() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revisions := make(map[string]int64, len(s.revisions))
+	for k, v := range s.revisions {
+		revisions[k] = v
+	}
+	return revisions
+}
+
+// Watch calls notify for every change under Prefix until ctx is done
+func (s *EtcdSource) Watch(ctx context.Context, notify func()) error {
+	e := errors.New(`etcd: no endpoints`)
+	for _, endpoint := range s.Endpoints {
+		e = s.watch(ctx, endpoint, notify)
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+	return e
+}
+
+// watch streams the watch responses of one endpoint. A stream closed by the
+// server is an error, so the caller retries.
+func (s *EtcdSource) watch(ctx context.Context, endpoint string, notify func()) error {
+	body, e := json.Marshal(map[string]interface{}{
+		"create_request": map[string]string{
+			"key":       base64.StdEncoding.EncodeToString([]byte(s.Prefix)),
+			"range_end": base64.StdEncoding.EncodeToString(prefixEnd(s.Prefix)),
+		},
+	})
+	if e != nil {
+		return e
+	}
+
+	req, e := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/v3/watch", bytes.NewReader(body))
+	if e != nil {
+		return e
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// the stream stays open, so the client timeout must not apply
+	client := *s.Client
+	client.Timeout = 0
+	r, e := client.Do(req)
+	if e != nil {
+		return e
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(r.Body)
+		return fmt.Errorf(`etcd /v3/watch: %s: %s`, r.Status, bytes.TrimSpace(data))
+	}
+
+	dec := json.NewDecoder(r.Body)
+	for {
+		var msg struct {
+			Result struct {
+				Created bool              `json:"created"`
+				Events  []json.RawMessage `json:"events"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if e := dec.Decode(&msg); e != nil {
+			if e == io.EOF {
+				return errors.New(`etcd: watch stream closed`)
+			}
+			return e
+		}
+		if msg.Error != nil {
+			return errors.New(`etcd: ` + msg.Error.Message)
+		}
+		if len(msg.Result.Events) > 0 {
+			notify()
+		}
+	}
+}
+
+func (s *EtcdSink) callContext(ctx context.Context, path string, req interface{}, resp interface{}) error {
+	body, e := json.Marshal(req)
+	if e != nil {
+		return e
+	}
+
+	hr, e := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint+path, bytes.NewReader(body))
+	if e != nil {
+		return e
+	}
+	hr.Header.Set("Content-Type", "application/json")
+
+	r, e := s.Client.Do(hr)
+	if e != nil {
+		return e
+	}
+	defer r.Body.Close()
+
+	data, e := io.ReadAll(r.Body)
+	if e != nil {
+		return e
+	}
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf(`etcd %s: %s: %s`, path, r.Status, bytes.TrimSpace(data))
+	}
+	return json.Unmarshal(data, resp)
+}
+
+// prefixEnd returns the etcd range end covering every key with prefix
+func prefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0} // all keys
+}
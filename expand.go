@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Read string property with ${name} references replaced at read time.
+// A reference resolves to the (expanded) property name, else to the
+// environment variable name; ${name:-default} supplies a default and $$ is a
+// literal $. Cyclic references are left unexpanded.
+func (c *Config) GetExpanded(name string) string {
+	if c.parent != nil {
+		return c.parent.GetExpanded(c.subPrefix + normalizeKey(name))
+	}
+	val, ok := c.lookupRaw(name)
+	if !ok {
+		return ``
+	}
+	return c.expandValue(val, map[string]bool{normalizeKey(name): true})
+}
+
+// Expand ${name} references in every getter like GetExpanded does, e.g.
+// url = http://${server.host}:${server.port}/api. References are resolved
+// when the property is read, so they follow Set and Reload. GetRaw and
+// GetAll return the values as written.
+func (c *Config) SetInterpolate(enable bool) {
+	if c.parent != nil {
+		c.parent.SetInterpolate(enable)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interpolate = enable
+}
+
+// Replace ${VAR} and ${VAR:-default} in values read from config files with
+// environment variables when the files are read, e.g. host = ${DB_HOST:-localhost}.
+// $$ is a literal $. Unset variables without default are kept as written.
+// Call before Open.
+func (c *Config) SetExpandEnv(enable bool) {
+	if c.parent != nil {
+		c.parent.SetExpandEnv(enable)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expandEnv = enable
+}
+
+// expandEnv expands environment references in every value of storage
+func expandEnv(storage map[string]string) {
+	for k, v := range storage {
+		storage[k] = expand(v, os.LookupEnv)
+	}
+}
+
+// expandValue expands references in val, visiting holds the properties being
+// expanded to detect cycles
+func (c *Config) expandValue(val string, visiting map[string]bool) string {
+	return expand(val, func(ref string) (string, bool) {
+		key := normalizeKey(ref)
+		if v, ok := c.lookupRaw(key); ok {
+			if visiting[key] {
+				return ``, false
+			}
+			visiting[key] = true
+			defer delete(visiting, key)
+			return c.expandValue(v, visiting), true
+		}
+		return os.LookupEnv(ref)
+	})
+}
+
+// expand replaces ${name} and ${name:-default} in s using resolve. $$ is a
+// literal $. Unresolved references without default are kept as written.
+func expand(s string, resolve func(name string) (string, bool)) string {
+	if !strings.Contains(s, `$`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		if s[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		if s[i+1] != '{' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		end := closingBrace(s, i+2)
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+
+		ref := s[i+2 : end]
+		name, def, hasDefault := strings.Cut(ref, `:-`)
+		if val, ok := resolve(name); ok && (val != `` || !hasDefault) {
+			b.WriteString(val)
+		} else if hasDefault {
+			b.WriteString(expand(def, resolve))
+		} else {
+			b.WriteString(s[i : end+1])
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// closingBrace returns the index of the } matching a ${ opened before start
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,40 @@
+package config
+
+// Read the first of names that is set, e.g. GetStringAny("server.listen",
+// "server.addr") while server.addr is being renamed. If none is set will
+// return empty string.
+func (c *Config) GetStringAny(names ...string) string {
+	if name, ok := c.firstSet(names); ok {
+		return c.GetString(name)
+	}
+	return ``
+}
+
+// Read the first of names that is set as integer. If none is set or the
+// value is invalid will return 0.
+func (c *Config) GetIntAny(names ...string) int {
+	if name, ok := c.firstSet(names); ok {
+		return c.GetInt(name)
+	}
+	return 0
+}
+
+// Read the first of names that is set as boolean. If none is set or the
+// value is invalid will return false.
+func (c *Config) GetBoolAny(names ...string) bool {
+	if name, ok := c.firstSet(names); ok {
+		return c.GetBool(name)
+	}
+	return false
+}
+
+// firstSet returns the first of names with a value. Later names are not
+// looked up, so they are not reported to OnAccess.
+func (c *Config) firstSet(names []string) (string, bool) {
+	for _, name := range names {
+		if _, ok := c.lookupRaw(name); ok {
+			return name, true
+		}
+	}
+	return ``, false
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Field names a property and the variable Fetch stores it in
+type Field struct {
+	Name string
+	Dest interface{}
+}
+
+// FetchError lists every property Fetch could not read
+type FetchError struct {
+	Errors []error
+}
+
+func (e *FetchError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, `; `)
+}
+
+func (e *FetchError) Unwrap() []error {
+	return e.Errors
+}
+
+// Read several properties into typed variables from the same version of the
+// config, e.g. Fetch(Field{"server.port", &port}, Field{"server.host", &host}).
+// Dest must be a pointer; values are converted like MapToStructNested, so
+// structs, slices and maps are filled from the keys below the name. Every
+// field is tried and a *FetchError naming all failing properties is returned.
+func (c *Config) Fetch(fields ...Field) error {
+	if c.parent != nil {
+		prefixed := make([]Field, len(fields))
+		for i, f := range fields {
+			prefixed[i] = Field{Name: c.subPrefix + normalizeKey(f.Name), Dest: f.Dest}
+		}
+		return c.parent.Fetch(prefixed...)
+	}
+
+	values := make([]interface{}, len(fields))
+
+	c.mu.RLock()
+	d := &decoder{numbers: c.numbers, coerce: c.coercion()}
+	for i, f := range fields {
+		values[i] = c.fetchValue(normalizeKey(f.Name))
+	}
+	c.mu.RUnlock()
+
+	var errs []error
+	for i, f := range fields {
+		var e error
+		rv := reflect.ValueOf(f.Dest)
+		switch {
+		case rv.Kind() != reflect.Ptr || rv.IsNil():
+			e = fmt.Errorf(`config: %s: destination must be a non-nil pointer, got %T`, f.Name, f.Dest)
+		case values[i] == nil:
+			e = c.notFound(f.Name)
+		default:
+			e = d.decodeValue(f.Name, values[i], rv.Elem())
+		}
+		if e != nil {
+			errs = append(errs, c.scrub(e))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &FetchError{Errors: errs}
+	}
+	return nil
+}
+
+// fetchValue returns the value of property name, or the nested properties
+// below it. Caller must hold c.mu.
+func (c *Config) fetchValue(name string) interface{} {
+	if val, ok := c.lookupEnv(name); ok {
+		return val
+	}
+	if val, ok := c.storage[name]; ok {
+		return val
+	}
+
+	prefix := name + "."
+	sub := make(map[string]string)
+	for k, v := range c.storage {
+		if strings.HasPrefix(k, prefix) {
+			sub[k[len(prefix):]] = v
+		}
+	}
+	if len(sub) == 0 {
+		return nil
+	}
+	return arrayify(Unflatten(sub))
+}
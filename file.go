@@ -2,30 +2,57 @@ package config
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
-	"regexp"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type File struct {
 	filename string
+	data     []byte // content of a reader source, nil for files on disk
 }
 
-const (
-	strRootLine = `^(?Ui)\s*([-]|)\[([a-z0-9]+)\].*$`
-	strLine     = `^(?Ui)\s*([a-z0-9_.]+)\s*=\s*(.*)(\s+(?:#|/{2,}).*|)\s*$`
-	strInclude  = `^include\s*(.*)\s*`
-)
-
 func NewFile(name string) File {
 	return File{
 		filename: name,
 	}
 }
 
+// open returns the content of the file, or of the reader it was read from
+func (f *File) open(c *Config) (io.ReadCloser, error) {
+	if f.data != nil {
+		return io.NopCloser(bytes.NewReader(f.data)), nil
+	}
+	return c.filesystem().Open(f.filename)
+}
+
+func (f *File) readAll(c *Config) ([]byte, error) {
+	if f.data != nil {
+		return f.data, nil
+	}
+	return readFile(c.filesystem(), f.filename)
+}
+
+// Read config file. The format is the one set with Config.SetFileFormat,
+// otherwise it is chosen by the file extension and, for unknown extensions,
+// by sniffing the content. Parsers added with RegisterParser are consulted
+// first.
 func (f *File) Read(c *Config) error {
-	fi, e := os.Open(f.filename)
+	format := c.formats[f.filename]
+	if format == `` {
+		format = detectFormat(c.filesystem(), f.filename)
+	}
+	return f.readFormat(c, format)
+}
+
+// Read INI style config file
+func (f *File) ReadIni(c *Config) error {
+	fi, e := f.open(c)
 	if e != nil {
 		return e
 	}
@@ -34,42 +61,198 @@ func (f *File) Read(c *Config) error {
 	fmt.Println(`Read config:`, f.filename)
 	c.file = append(c.file, f.filename)
 
-	scanner := bufio.NewScanner(fi)
-	regexLine := regexp.MustCompile(strLine)
-	regexRoot := regexp.MustCompile(strRootLine)
-	regexInclude := regexp.MustCompile(strInclude)
+	tokenize := tokenizeIniLine
+	if c.iniCompat {
+		tokenize = legacyIniTokenizer()
+	}
 
+	scanner := bufio.NewScanner(fi)
 	root := ``
+	arrays := make(map[string]int)
+	commaArrays := make(map[string]int)
+	lineNo := 0
+	var comments []string // comment lines above the current line
 
 	for scanner.Scan() {
-		strLine := scanner.Text()
+		lineNo++
+		line, e := tokenize(scanner.Text())
+		if e != nil {
+			e = fmt.Errorf(`%s:%d: %v`, f.filename, lineNo, e)
+			if c.iniStrict {
+				return e
+			}
+			fmt.Println(`Skip config line:`, e)
+			comments = nil
+			continue
+		}
+
+		if line.kind != iniBlank {
+			c.describeKey(iniDescribedKey(root, line), comments, line.comment)
+			comments = nil
+		}
+
+		switch line.kind {
+		case iniBlank:
+			if line.comment == `` {
+				comments = nil // a blank line detaches the comments
+			} else {
+				comments = append(comments, line.comment)
+			}
+		case iniValue:
+			key, val := line.key, line.value
+			if strings.HasSuffix(key, `[]`) {
+				// key[] = value appends key.0, key.1, ...
+				keyPath := root + "." + normalizeKey(strings.TrimSuffix(key, `[]`))
+				c.storage[keyPath+"."+strconv.Itoa(arrays[keyPath])] = val
+				arrays[keyPath]++
+				continue
+			}
 
-		if matches := regexLine.FindStringSubmatch(strLine); len(matches) > 0 {
-			key := strings.TrimSpace(matches[1])
-			val := strings.TrimSpace(matches[2])
-			if strings.HasPrefix(val, `"`) && strings.HasSuffix(val, `"`) {
-				val = val[1 : len(val)-1]
+			keyPath := root + "." + normalizeKey(key)
+			if c.repeatedKeyArrays {
+				if n, ok := arrays[keyPath]; ok {
+					if n == 1 {
+						c.storage[keyPath+".0"] = c.storage[keyPath]
+						delete(c.storage, keyPath)
+					}
+					c.storage[keyPath+"."+strconv.Itoa(n)] = val
+					arrays[keyPath]++
+					continue
+				}
+				arrays[keyPath] = 1
 			}
-			keyPath := root + "." + key
 			c.storage[keyPath] = val
-		} else if matches := regexRoot.FindStringSubmatch(strLine); len(matches) > 0 {
-			root = matches[2]
-		} else if matches := regexInclude.FindStringSubmatch(strLine); len(matches) >= 2 {
-			path := matches[1]
-
-			if !contains(c.file, path) {
-				f2 := NewFile(path)
-				e := f2.Read(c)
-				if e != nil {
-					return e
+
+			if c.iniCommaArrays {
+				// drop the items of an earlier list assigned to the same key
+				for i := 0; i < commaArrays[keyPath]; i++ {
+					delete(c.storage, keyPath+"."+strconv.Itoa(i))
+				}
+				delete(commaArrays, keyPath)
+				if !line.quoted && strings.Contains(val, ",") {
+					items := strings.Split(val, ",")
+					for i, item := range items {
+						c.storage[keyPath+"."+strconv.Itoa(i)] = strings.TrimSpace(item)
+					}
+					commaArrays[keyPath] = len(items)
+				}
+			}
+		case iniSection:
+			root = normalizeKey(line.section)
+			if line.sub != `` {
+				// [server "api"] is the subsection api of server
+				root += "." + EscapeKey(line.sub)
+			}
+		case iniInclude:
+			paths, e := f.includePaths(c, line.include)
+			if e != nil {
+				return e
+			}
+
+			for _, path := range paths {
+				if !contains(c.file, path) {
+					f2 := NewFile(path)
+					e := f2.Read(c)
+					if e != nil {
+						return e
+					}
+					c.file = append(c.file, path)
+				} else {
+					fmt.Println(`Skippp.. already read`, path)
 				}
-				c.file = append(c.file, path)
-			} else {
-				fmt.Println(`Skippp.. already read`, path)
 			}
 		}
 	}
-	return nil
+	return scanner.Err()
+}
+
+// includePaths resolves an include directive relative to the including file
+// and expands glob patterns (include conf.d/*.conf) in lexical order, noting
+// them in c for the snapshot. Paths that only exist relative to the working
+// directory are still accepted.
+func (f *File) includePaths(c *Config, include string) ([]string, error) {
+	fsys := c.filesystem()
+	path := include
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(f.filename), include)
+	}
+
+	if strings.ContainsAny(include, `*?[`) {
+		matches, e := fsys.Glob(path)
+		if e != nil {
+			return nil, fmt.Errorf(`%s: include %s: %v`, f.filename, include, e)
+		}
+		sort.Strings(matches)
+		if c.includeGlobs != nil {
+			c.includeGlobs[path] = matches
+		}
+		return matches, nil
+	}
+
+	if _, e := fsys.Stat(path); e != nil {
+		if _, e := fsys.Stat(include); e == nil {
+			return []string{include}, nil
+		}
+	}
+	return []string{path}, nil
+}
+
+// Read INI files with the original regular expression based reader, which
+// only accepts [a-z0-9] section names and ignores lines it does not match
+func (c *Config) SetIniCompat(enable bool) {
+	if c.parent != nil {
+		c.parent.SetIniCompat(enable)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.iniCompat = enable
+}
+
+// Fail reading INI files with a line that is not a section, include,
+// comment or key = value, or has an unterminated quote. By default such
+// lines are skipped with a warning, like the original reader did.
+func (c *Config) SetIniStrict(enable bool) {
+	if c.parent != nil {
+		c.parent.SetIniStrict(enable)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.iniStrict = enable
+}
+
+// Read keys repeated within an INI file as arrays (key.0, key.1, ...)
+// instead of letting the last value win. `key[] = value` always appends.
+func (c *Config) SetRepeatedKeysAsArray(enable bool) {
+	if c.parent != nil {
+		c.parent.SetRepeatedKeysAsArray(enable)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.repeatedKeyArrays = enable
+}
+
+// Also read unquoted INI values containing commas as arrays: hosts = a, b, c
+// keeps hosts as written and adds hosts.0, hosts.1 and hosts.2 with the
+// trimmed items. Quoted values are never split.
+func (c *Config) SetIniCommaArrays(enable bool) {
+	if c.parent != nil {
+		c.parent.SetIniCommaArrays(enable)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.iniCommaArrays = enable
 }
 
 func contains(s []string, str string) bool {
@@ -81,3 +264,32 @@ func contains(s []string, str string) bool {
 
 	return false
 }
+
+// writeFileAtomic replaces path with data through a temporary file. The
+// mode of an existing file is kept; new files are 0600 since overrides,
+// snapshots and compiled files may hold secrets.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o600)
+	if fi, e := os.Stat(path); e == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, e := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if e != nil {
+		return e
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, e := tmp.Write(data); e != nil {
+		tmp.Close()
+		return e
+	}
+	if e := tmp.Chmod(mode); e != nil {
+		tmp.Close()
+		return e
+	}
+	if e := tmp.Close(); e != nil {
+		return e
+	}
+	return os.Rename(tmp.Name(), path)
+}
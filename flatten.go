@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flatten converts a nested value (maps, slices and scalars, e.g. decoded
+// JSON) into dot separated keys. Array elements use their index as key part.
+func Flatten(v interface{}) map[string]string {
+	out := make(map[string]string)
+	flatten("", v, out)
+	return out
+}
+
+func flatten(prefix string, v interface{}, out map[string]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			flatten(joinKey(prefix, EscapeKey(k)), child, out)
+		}
+	case map[string]string:
+		for k, child := range val {
+			out[joinKey(prefix, EscapeKey(k))] = child
+		}
+	case []interface{}:
+		for i, child := range val {
+			flatten(joinKey(prefix, strconv.Itoa(i)), child, out)
+		}
+	default:
+		if prefix != `` {
+			out[prefix] = scalarString(val)
+		}
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == `` {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func scalarString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ``
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// Unflatten rebuilds the nested structure of dot separated keys, honoring
+// escaped dots (see SplitKey). Objects whose keys are exactly 0..n-1 become
+// arrays. When a key is both a scalar and a parent of other keys, the nested
+// object wins.
+func Unflatten(flat map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(flat))
+	for k, v := range flat {
+		values[k] = v
+	}
+	return unflattenValues(values)
+}
+
+// unflattenValues is Unflatten for values already converted from strings
+func unflattenValues(flat map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := make(map[string]interface{})
+	for _, k := range keys {
+		parts := SplitKey(strings.TrimPrefix(k, "."))
+		node := root
+		for i, p := range parts {
+			if i == len(parts)-1 {
+				if _, isMap := node[p].(map[string]interface{}); !isMap {
+					node[p] = flat[k]
+				}
+				break
+			}
+			child, ok := node[p].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[p] = child
+			}
+			node = child
+		}
+	}
+	for k, child := range root {
+		root[k] = arrayify(child)
+	}
+	return root
+}
+
+func arrayify(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for k, child := range m {
+		m[k] = arrayify(child)
+	}
+	if len(m) == 0 {
+		return m
+	}
+	arr := make([]interface{}, len(m))
+	for k, child := range m {
+		i, e := strconv.Atoi(k)
+		if e != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+		arr[i] = child
+	}
+	return arr
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// nested is a random nested structure as decoded from JSON: objects, arrays
+// and string scalars. Objects and arrays are never empty, since Flatten has
+// no key to keep them under.
+type nested struct {
+	v interface{}
+}
+
+func (nested) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(nested{randomObject(r, 4)})
+}
+
+// keyRunes makes keys that need escaping (dots, brackets, backslashes) as
+// well as keys that look like array indexes
+const keyRunes = `abcxyz_-01.[]\`
+
+func randomKey(r *rand.Rand) string {
+	n := 1 + r.Intn(6)
+	b := make([]byte, n)
+	b[0] = 'a' + byte(r.Intn(26)) // a letter first, so objects never turn into arrays
+	for i := 1; i < n; i++ {
+		b[i] = keyRunes[r.Intn(len(keyRunes))]
+	}
+	return string(b)
+}
+
+func randomValue(r *rand.Rand, depth int) interface{} {
+	if depth == 0 {
+		return randomScalar(r)
+	}
+	switch r.Intn(3) {
+	case 0:
+		return randomObject(r, depth-1)
+	case 1:
+		arr := make([]interface{}, 1+r.Intn(4))
+		for i := range arr {
+			arr[i] = randomValue(r, depth-1)
+		}
+		return arr
+	}
+	return randomScalar(r)
+}
+
+func randomObject(r *rand.Rand, depth int) map[string]interface{} {
+	m := make(map[string]interface{})
+	for n := 1 + r.Intn(4); len(m) < n; {
+		m[randomKey(r)] = randomValue(r, depth)
+	}
+	return m
+}
+
+func randomScalar(r *rand.Rand) string {
+	switch r.Intn(4) {
+	case 0:
+		return ``
+	case 1:
+		return strconv.Itoa(r.Int())
+	case 2:
+		return `a.b[0]\c`
+	}
+	return randomKey(r)
+}
+
+func TestFlattenUnflattenRoundTrip(t *testing.T) {
+	roundTrip := func(n nested) bool {
+		return reflect.DeepEqual(Unflatten(Flatten(n.v)), n.v)
+	}
+	if e := quick.Check(roundTrip, &quick.Config{MaxCount: 2000}); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestFlattenUnflattenFlattenStable(t *testing.T) {
+	stable := func(n nested) bool {
+		flat := Flatten(n.v)
+		return reflect.DeepEqual(Flatten(Unflatten(flat)), flat)
+	}
+	if e := quick.Check(stable, &quick.Config{MaxCount: 2000}); e != nil {
+		t.Error(e)
+	}
+}
+
+// FuzzUnflattenStable checks that any set of keys, including ones Unflatten
+// has to drop or reshape, is stable after one flatten→unflatten→flatten.
+// Keys with empty parts are skipped: Unflatten drops the leading dot of keys
+// outside any section, so an empty part can not be told from the root.
+func FuzzUnflattenStable(f *testing.F) {
+	f.Add(`a.b`, `a.b.c`, `x`)
+	f.Add(`list.0`, `list.1`, `item`)
+	f.Add(`a\.b`, `a[0]`, `v`)
+	f.Add(`.root`, `0`, ``)
+
+	f.Fuzz(func(t *testing.T, k1, k2, val string) {
+		if !fullKey(k1) || !fullKey(k2) {
+			return
+		}
+		once := Flatten(Unflatten(map[string]string{k1: val, k2: val + `2`}))
+		twice := Flatten(Unflatten(once))
+		if !reflect.DeepEqual(once, twice) {
+			t.Errorf(`%q, %q: %v then %v`, k1, k2, once, twice)
+		}
+	})
+}
+
+// fullKey reports whether every part of key is non-empty
+func fullKey(key string) bool {
+	for _, part := range SplitKey(strings.TrimPrefix(key, ".")) {
+		if part == `` {
+			return false
+		}
+	}
+	return true
+}
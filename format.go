@@ -0,0 +1,123 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var extFormats = map[string]string{
+	".ini":        `ini`,
+	".json":       `json`,
+	".jsonc":      `json`,
+	".toml":       `toml`,
+	".env":        `env`,
+	".properties": `properties`,
+	".msgpack":    `msgpack`,
+	".cbor":       `cbor`,
+}
+
+// Force the format of a config file instead of detecting it, e.g.
+// SetFileFormat("app.conf", "json"). Known formats are ini, json, toml, env,
+// properties, msgpack, cbor and any extension registered with RegisterParser.
+func (c *Config) SetFileFormat(file string, format string) {
+	if c.parent != nil {
+		c.parent.SetFileFormat(file, format)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setFormat(file, strings.TrimPrefix(strings.ToLower(format), "."))
+}
+
+// setFormat replaces c.formats with a copy holding format for name, or
+// without name when format is blank. The map is never changed in place
+// since loads read it without the lock. Caller must hold c.mu.
+func (c *Config) setFormat(name string, format string) {
+	formats := make(map[string]string, len(c.formats)+1)
+	for k, v := range c.formats {
+		formats[k] = v
+	}
+	if format != `` {
+		formats[name] = format
+	} else {
+		delete(formats, name)
+	}
+	c.formats = formats
+}
+
+// detectFormat chooses the format by registered parser, extension and finally content
+func detectFormat(fsys FS, filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if _, ok := lookupParser(ext); ok {
+		return strings.TrimPrefix(ext, ".")
+	}
+	if isDotenv(filename) {
+		return `env`
+	}
+	if format, ok := extFormats[ext]; ok {
+		return format
+	}
+	return sniffFile(fsys, filename)
+}
+
+func sniffFile(fsys FS, filename string) string {
+	fi, e := fsys.Open(filename)
+	if e != nil {
+		return `ini` // let the reader report the error
+	}
+	defer fi.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(fi, head)
+	return sniffFormat(head[:n])
+}
+
+// sniffFormat guesses the format from the first bytes of a file: JSON objects
+// and arrays, otherwise INI. There is no built-in YAML reader, so YAML files
+// need a parser registered with RegisterParser.
+func sniffFormat(head []byte) string {
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	head = bytes.TrimLeft(head, " \t\r\n")
+
+	switch {
+	case bytes.HasPrefix(head, []byte("{")):
+		return `json`
+	case bytes.HasPrefix(head, []byte("[")):
+		// [section] is INI, anything else ([1, 2], [{...}]) is a JSON array
+		rest := bytes.TrimLeft(head[1:], " \t")
+		if len(rest) > 0 && (isBareKeyChar(rest[0]) && !isDigit(rest[0]) && rest[0] != '-') {
+			return `ini`
+		}
+		return `json`
+	}
+	return `ini`
+}
+
+func (f *File) readFormat(c *Config, format string) error {
+	if fn, ok := lookupParser("." + format); ok {
+		return f.readWithParser(c, fn)
+	}
+
+	switch format {
+	case `ini`:
+		return f.ReadIni(c)
+	case `json`:
+		return f.ReadJSON(c)
+	case `toml`:
+		return f.ReadTOML(c)
+	case `env`:
+		return f.ReadEnv(c)
+	case `properties`:
+		return f.ReadProperties(c)
+	case `msgpack`:
+		return f.ReadMsgpack(c)
+	case `cbor`:
+		return f.ReadCBOR(c)
+	}
+	return fmt.Errorf(`%s: unsupported config format %q`, f.filename, format)
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FS is the filesystem config files, includes, OpenDir directories,
+// snapshots and overrides are read from. Names are passed as given to Open,
+// the way os.Open takes them.
+type FS interface {
+	Open(name string) (fs.File, error)
+	Stat(name string) (fs.FileInfo, error)
+	Glob(pattern string) ([]string, error)
+}
+
+// WriteFS is an FS that can also replace files. Snapshots, compiled configs
+// and persisted overrides are only written when the FS is a WriteFS.
+type WriteFS interface {
+	FS
+	// WriteFile replaces name with data atomically
+	WriteFile(name string, data []byte) error
+}
+
+// Clock is the time source of a Config and of the file watcher
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+var errReadOnlyFS = errors.New(`config: filesystem is read only`)
+
+// OSFS is the operating system filesystem, used by default
+type OSFS struct{}
+
+func (OSFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFS) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+func (OSFS) WriteFile(name string, data []byte) error {
+	return writeFileAtomic(name, data)
+}
+
+// IOFS adapts an io/fs filesystem such as embed.FS, fstest.MapFS or
+// afero.NewIOFS. Leading slashes and ./ are removed from names, as io/fs
+// only accepts unrooted paths.
+func IOFS(fsys fs.FS) FS {
+	return &ioFS{fsys: fsys}
+}
+
+type ioFS struct {
+	fsys fs.FS
+}
+
+func (f *ioFS) Open(name string) (fs.File, error) {
+	return f.fsys.Open(ioName(name))
+}
+
+func (f *ioFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, ioName(name))
+}
+
+func (f *ioFS) Glob(pattern string) ([]string, error) {
+	return fs.Glob(f.fsys, ioName(pattern))
+}
+
+func ioName(name string) string {
+	name = path.Clean(filepath.ToSlash(name))
+	name = strings.TrimLeft(name, "/")
+	if name == `` {
+		return `.`
+	}
+	return name
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Read config files through fsys instead of the operating system, e.g.
+// SetFS(IOFS(embedded)). Parsers added with RegisterParser and the change
+// feed still use the operating system. Call before Open. nil restores OSFS.
+func (c *Config) SetFS(fsys FS) {
+	if c.parent != nil {
+		c.parent.SetFS(fsys)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fsys = fsys
+}
+
+// Use clk instead of the system clock for reload times, hook timings and
+// change feed entries. nil restores the system clock.
+func (c *Config) SetClock(clk Clock) {
+	if c.parent != nil {
+		c.parent.SetClock(clk)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clock = clk
+}
+
+// filesystem returns the FS of c. Caller must hold c.mu.
+func (c *Config) filesystem() FS {
+	if c.fsys == nil {
+		return OSFS{}
+	}
+	return c.fsys
+}
+
+// now returns the time of the clock of c. Caller must hold c.mu.
+func (c *Config) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// readFile reads the whole file name from fsys
+func readFile(fsys FS, name string) ([]byte, error) {
+	if rf, ok := fsys.(fs.ReadFileFS); ok {
+		return rf.ReadFile(name)
+	}
+	fi, e := fsys.Open(name)
+	if e != nil {
+		return nil, e
+	}
+	defer fi.Close()
+
+	return io.ReadAll(fi)
+}
+
+// writeFile replaces name with data when fsys can write
+func writeFile(fsys FS, name string, data []byte) error {
+	wfs, ok := fsys.(WriteFS)
+	if !ok {
+		return errReadOnlyFS
+	}
+	return wfs.WriteFile(name, data)
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+// Read property key as T, e.g. Get[time.Duration](c, "http.timeout").
+// Scalars, time.Duration, time.Time and types implementing
+// encoding.TextUnmarshaler are parsed from the value; slices, maps and
+// structs are decoded from the properties under key like GetArrayToStruct.
+// Returns ErrKeyNotFound if property is not exists.
+func Get[T any](c *Config, key string) (T, error) {
+	var v T
+	var e error
+	switch p := interface{}(&v).(type) {
+	case *time.Duration:
+		*p, e = c.GetDurationE(key)
+		return v, e
+	case *time.Time:
+		*p, e = c.GetTimeE(key)
+		return v, e
+	case *[]string:
+		*p, e = c.GetStringSliceE(key)
+		return v, e
+	case *[]int:
+		*p, e = c.GetIntSliceE(key)
+		return v, e
+	case *[]float64:
+		*p, e = c.GetFloatSliceE(key)
+		return v, e
+	}
+
+	var in interface{}
+	if val, ok := c.lookup(key); ok {
+		in = val
+	} else if nested := c.GetStringMapNested(key); len(nested) > 0 {
+		in = arrayify(nested)
+	} else {
+		return v, c.notFound(key)
+	}
+
+	if e := c.decoder().decodeValue(normalizeKey(key), in, reflect.ValueOf(&v).Elem()); e != nil {
+		var zero T
+		return zero, c.scrub(e)
+	}
+	return v, nil
+}
+
+// Read property key as T like Get, or return defValue if property is not
+// exists or invalid
+func GetOr[T any](c *Config, key string, defValue T) T {
+	v, e := Get[T](c, key)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitSource reads a config file from a git repository, cloned into Dir
+// with the git command and updated on every load. The commit of the loaded
+// file is reported as Version in SourceStatus and GetStats.
+type GitSource struct {
+	URL  string // repository, anything git clone accepts
+	Ref  string // branch or tag, the default branch when blank
+	Path string // config file in the repository
+	Dir  string // working copy, a directory under the user cache by default
+
+	// Format of the file, by default chosen like Open does
+	Format string
+	// Interval between checks for new commits with git ls-remote, zero
+	// disables them
+	Interval time.Duration
+
+	mu     sync.Mutex
+	commit string
+}
+
+// Create source for the file path in the repository url at ref, checked for
+// new commits every minute
+func NewGitSource(url string, ref string, path string) *GitSource {
+	return &GitSource{URL: url, Ref: ref, Path: path, Interval: time.Minute}
+}
+
+// Load clones or updates the working copy and reads the file
+func (s *GitSource) Load(ctx context.Context) (map[string]string, error) {
+	dir, e := s.dir()
+	if e != nil {
+		return nil, e
+	}
+
+	if _, e := os.Stat(filepath.Join(dir, `.git`)); e != nil {
+		args := []string{`clone`, `--depth`, `1`}
+		if s.Ref != `` {
+			args = append(args, `--branch`, s.Ref)
+		}
+		if _, e := runGit(ctx, ``, append(args, s.URL, dir)...); e != nil {
+			return nil, e
+		}
+	} else {
+		ref := s.Ref
+		if ref == `` {
+			ref = `HEAD`
+		}
+		if _, e := runGit(ctx, dir, `fetch`, `--depth`, `1`, `origin`, ref); e != nil {
+			return nil, e
+		}
+		if _, e := runGit(ctx, dir, `reset`, `--hard`, `FETCH_HEAD`); e != nil {
+			return nil, e
+		}
+	}
+
+	commit, e := runGit(ctx, dir, `rev-parse`, `HEAD`)
+	if e != nil {
+		return nil, e
+	}
+
+	data, e := os.ReadFile(filepath.Join(dir, filepath.FromSlash(s.Path)))
+	if e != nil {
+		return nil, e
+	}
+	props, e := parseObject(s.Path, data, s.Format, ``)
+	if e != nil {
+		return nil, e
+	}
+
+	s.mu.Lock()
+	s.commit = commit
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Watch calls notify when the ref points to another commit than the loaded
+// one
+func (s *GitSource) Watch(ctx context.Context, notify func()) error {
+	return pollWatch(ctx, s.Interval, func() {
+		ref := s.Ref
+		if ref == `` {
+			ref = `HEAD`
+		}
+		out, e := runGit(ctx, ``, `ls-remote`, s.URL, ref)
+		if e != nil {
+			notify() // the reload records the error
+			return
+		}
+
+		commit, _, _ := strings.Cut(out, "\t")
+		if commit != s.Version() {
+			notify()
+		}
+	})
+}
+
+// Version returns the commit SHA of the loaded file
+func (s *GitSource) Version() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit
+}
+
+func (s *GitSource) pollInterval() time.Duration {
+	return s.Interval
+}
+
+// dir returns the working copy directory
+func (s *GitSource) dir() (string, error) {
+	if s.Dir != `` {
+		return s.Dir, nil
+	}
+	cache, e := os.UserCacheDir()
+	if e != nil {
+		return ``, e
+	}
+
+	name := strings.NewReplacer(`/`, `_`, `:`, `_`, `@`, `_`).Replace(s.URL + `@` + s.Ref)
+	return filepath.Join(cache, `go-config`, `git`, name), nil
+}
+
+// runGit runs git in dir and returns its trimmed output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, `git`, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), `GIT_TERMINAL_PROMPT=0`)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if e := cmd.Run(); e != nil {
+		return ``, fmt.Errorf(`git %s: %v: %s`, args[0], e, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
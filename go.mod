@@ -2,10 +2,4 @@ module github.com/budimanlai/go-config
 
 go 1.19
 
-require github.com/stretchr/testify v1.5.1
-
-require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v2 v2.2.2 // indirect
-)
+require go.uber.org/goleak v1.2.1
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// RemoteOptions configures a config source read with OpenRemote
+type RemoteOptions struct {
+	// Format of the document, e.g. json or ini. When blank it is taken from
+	// the Content-Type, then the URL extension, then the content.
+	Format string
+	// Interval between polls, zero fetches the document only on Open
+	Interval time.Duration
+	// Client used for the requests, a client with a 30s timeout by default
+	Client *http.Client
+	// Header is added to every request, e.g. Authorization
+	Header http.Header
+}
+
+type remoteSource struct {
+	url          string
+	opts         RemoteOptions
+	etag         string
+	lastModified string
+	stop         chan struct{}
+}
+
+// Read config from an HTTP(S) URL, merged after the sources already opened.
+// With an Interval the URL is polled using ETag and If-Modified-Since, and a
+// changed document is applied with Reload, so OnReload functions and change
+// subscribers see it like a changed file. Close stops the polling.
+func (c *Config) OpenRemote(rawURL string, opts RemoteOptions) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	u, e := url.Parse(rawURL)
+	if e != nil {
+		return e
+	}
+	if u.Scheme != `http` && u.Scheme != `https` {
+		return fmt.Errorf(`config: unsupported remote URL %s`, rawURL)
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if e := c.checkNotClosed(); e != nil {
+		return e
+	}
+
+	r := &remoteSource{url: rawURL, opts: opts}
+	data, format, e := r.fetch()
+	if e != nil {
+		return e
+	}
+	if format == `` {
+		format = sniffFormat(data)
+	}
+
+	c.mu.Lock()
+	if _, ok := c.remotes[rawURL]; ok {
+		c.mu.Unlock()
+		return errors.New(`config: remote already opened: ` + rawURL)
+	}
+	c.setReader(rawURL, data)
+	c.setFormat(rawURL, format)
+	sources := c.sources
+	c.sources = append(append([]string(nil), sources...), rawURL)
+	c.mu.Unlock()
+
+	if _, e := c.load(); e != nil {
+		c.mu.Lock()
+		c.sources = sources
+		c.setReader(rawURL, nil)
+		c.setFormat(rawURL, ``)
+		c.mu.Unlock()
+		return e
+	}
+
+	c.mu.Lock()
+	if c.remotes == nil {
+		c.remotes = make(map[string]*remoteSource)
+	}
+	c.remotes[rawURL] = r
+	if opts.Interval > 0 {
+		r.stop = make(chan struct{})
+		go c.pollRemote(r, r.stop)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// pollRemote fetches r every interval until stop is closed
+func (c *Config) pollRemote(r *remoteSource, stop chan struct{}) {
+	for {
+		c.mu.RLock()
+		clock := c.clock
+		c.mu.RUnlock()
+		if clock == nil {
+			clock = systemClock{}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-clock.After(r.opts.Interval):
+		}
+
+		data, _, e := r.fetch()
+		if e != nil {
+			fmt.Println(`Read remote config failed:`, e)
+			c.recordSources([]string{r.url}, r.url, e)
+			continue
+		}
+		if data == nil {
+			c.recordSources([]string{r.url}, ``, nil) // not modified
+			continue
+		}
+
+		c.mu.Lock()
+		if c.remotes[r.url] != r {
+			c.mu.Unlock()
+			return
+		}
+		c.setReader(r.url, data)
+		c.mu.Unlock()
+
+		if e := c.Reload(); e != nil {
+			fmt.Println(`Reload config failed:`, e)
+		}
+	}
+}
+
+// stopRemotes ends the polling of every remote source. Caller must hold c.mu.
+func (c *Config) stopRemotes() {
+	for _, r := range c.remotes {
+		if r.stop != nil {
+			close(r.stop)
+			r.stop = nil
+		}
+	}
+}
+
+// fetch returns the document, or nil data when it was not modified since
+// the last fetch, and the format named by the response
+func (r *remoteSource) fetch() ([]byte, string, error) {
+	req, e := http.NewRequest(http.MethodGet, r.url, nil)
+	if e != nil {
+		return nil, ``, e
+	}
+	for k, v := range r.opts.Header {
+		req.Header[k] = v
+	}
+	if r.etag != `` {
+		req.Header.Set(`If-None-Match`, r.etag)
+	}
+	if r.lastModified != `` {
+		req.Header.Set(`If-Modified-Since`, r.lastModified)
+	}
+
+	resp, e := r.opts.Client.Do(req)
+	if e != nil {
+		return nil, ``, e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ``, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, ``, fmt.Errorf(`%s: %s`, r.url, resp.Status)
+	}
+
+	data, e := io.ReadAll(resp.Body)
+	if e != nil {
+		return nil, ``, fmt.Errorf(`%s: %v`, r.url, e)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	r.etag = resp.Header.Get(`ETag`)
+	r.lastModified = resp.Header.Get(`Last-Modified`)
+
+	format := strings.TrimPrefix(strings.ToLower(r.opts.Format), ".")
+	if format == `` {
+		format = remoteFormat(r.url, resp.Header.Get(`Content-Type`))
+	}
+	return data, format, nil
+}
+
+// remoteFormat chooses the format by Content-Type, then URL extension
+func remoteFormat(rawURL string, contentType string) string {
+	media, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case media == `application/json` || strings.HasSuffix(media, `+json`):
+		return `json`
+	case media == `application/toml`:
+		return `toml`
+	case media == `application/msgpack` || media == `application/x-msgpack`:
+		return `msgpack`
+	case media == `application/cbor`:
+		return `cbor`
+	}
+
+	if u, e := url.Parse(rawURL); e == nil {
+		if format, ok := extFormats[strings.ToLower(path.Ext(u.Path))]; ok {
+			return format
+		}
+	}
+	return ``
+}
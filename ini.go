@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	strRootLine = `^(?Ui)\s*([-]|)\[([a-z0-9]+)\].*$`
+	strLine     = `^(?Ui)\s*([a-z0-9_.]+)\s*=\s*(.*)(\s+(?:#|/{2,}).*|)\s*$`
+	strInclude  = `^include\s*(.*)\s*`
+)
+
+const (
+	iniBlank = iota
+	iniValue
+	iniSection
+	iniInclude
+)
+
+// iniLine is one tokenized INI line
+type iniLine struct {
+	kind    int
+	key     string
+	value   string
+	section string
+	sub     string
+	include string
+	quoted  bool
+	comment string // text of a comment line or an inline comment
+}
+
+// tokenizeIniLine reads one INI line. Values may be double quoted (with
+// backslash escapes) or single quoted (literal); unquoted values end at an
+// inline comment, a # or // preceded by whitespace.
+func tokenizeIniLine(s string) (iniLine, error) {
+	t := strings.TrimSpace(s)
+	switch {
+	case t == ``:
+		return iniLine{kind: iniBlank}, nil
+	case t[0] == '#' || t[0] == ';' || strings.HasPrefix(t, `//`):
+		return iniLine{kind: iniBlank, comment: commentText(t)}, nil
+	case t[0] == '[' || strings.HasPrefix(t, `-[`):
+		if line, ok, e := tokenizeIniSection(strings.TrimPrefix(t, `-`)); ok || e != nil {
+			return line, e
+		}
+	case strings.HasPrefix(t, `include`) && len(t) > 7 && (t[7] == ' ' || t[7] == '\t'):
+		path := strings.TrimSpace(t[7:])
+		if !strings.HasPrefix(path, `=`) {
+			if p, rest, e := iniQuoted(path); e == nil && p != `` && isIniComment(rest) {
+				path = p
+			}
+			return iniLine{kind: iniInclude, include: path}, nil
+		}
+	}
+
+	eq := iniKeyEnd(t)
+	if eq < 0 {
+		return iniLine{}, errors.New(`expected key = value`)
+	}
+	key := strings.TrimSpace(t[:eq])
+	if key == `` {
+		return iniLine{}, errors.New(`missing key`)
+	}
+
+	raw := strings.TrimSpace(t[eq+1:])
+	val, comment, e := iniValueText(raw)
+	if e != nil {
+		return iniLine{}, e
+	}
+	quoted := raw != `` && (raw[0] == '"' || raw[0] == '\'')
+	return iniLine{kind: iniValue, key: key, value: val, quoted: quoted, comment: comment}, nil
+}
+
+// tokenizeIniSection reads [name] or [name "sub"]. ok is false when the line
+// is not a section, e.g. a key written with bracket syntax.
+func tokenizeIniSection(t string) (iniLine, bool, error) {
+	end := -1
+	inQuote := false
+	for i := 1; i < len(t); i++ {
+		if inQuote && t[i] == '\\' {
+			i++
+			continue
+		}
+		if t[i] == '"' {
+			inQuote = !inQuote
+		} else if t[i] == ']' && !inQuote {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return iniLine{}, false, errors.New(`unterminated section`)
+	}
+	if !isIniComment(t[end+1:]) {
+		return iniLine{}, false, nil
+	}
+
+	inner := strings.TrimSpace(t[1:end])
+	line := iniLine{kind: iniSection, section: inner}
+	if q := strings.IndexByte(inner, '"'); q >= 0 {
+		sub, rest, e := iniQuoted(inner[q:])
+		if e != nil {
+			return iniLine{}, true, e
+		}
+		if strings.TrimSpace(rest) != `` {
+			return iniLine{}, true, errors.New(`unexpected text after subsection`)
+		}
+		line.section = strings.TrimSpace(inner[:q])
+		line.sub = sub
+	}
+	if line.section == `` {
+		return iniLine{}, true, errors.New(`empty section name`)
+	}
+	return line, true, nil
+}
+
+// iniKeyEnd returns the index of the = ending the key, skipping = inside
+// brackets and quotes of the bracket key syntax
+func iniKeyEnd(t string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(t); i++ {
+		ch := t[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\\':
+			i++
+		case ch == '[':
+			depth++
+		case ch == ']' && depth > 0:
+			depth--
+		case depth > 0 && (ch == '"' || ch == '\''):
+			quote = ch
+		case ch == '=' && depth == 0:
+			return i
+		}
+	}
+	return -1
+}
+
+// iniValueText returns the value and the text of its inline comment
+func iniValueText(v string) (string, string, error) {
+	if v != `` && (v[0] == '"' || v[0] == '\'') {
+		val, rest, e := iniQuoted(v)
+		if e != nil {
+			return ``, ``, e
+		}
+		if !isIniComment(rest) {
+			return ``, ``, errors.New(`unexpected text after quoted value`)
+		}
+		return val, commentText(rest), nil
+	}
+
+	for i := 1; i < len(v); i++ {
+		if (v[i-1] == ' ' || v[i-1] == '\t') && (v[i] == '#' || strings.HasPrefix(v[i:], `//`)) {
+			return strings.TrimSpace(v[:i]), commentText(v[i:]), nil
+		}
+	}
+	return v, ``, nil
+}
+
+// iniQuoted reads the quoted string at the start of s and returns it with
+// the text after the closing quote
+func iniQuoted(s string) (string, string, error) {
+	if s == `` || (s[0] != '"' && s[0] != '\'') {
+		return ``, s, errors.New(`expected quoted string`)
+	}
+	quote := s[0]
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == quote:
+			return b.String(), s[i+1:], nil
+		case ch == '\\' && quote == '"' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return ``, ``, errors.New(`unterminated quoted string`)
+}
+
+// commentText returns the text of a # ; or // comment
+func commentText(s string) string {
+	s = strings.TrimSpace(s)
+	for _, marker := range []string{`//`, `#`, `;`} {
+		if strings.HasPrefix(s, marker) {
+			return strings.TrimSpace(strings.TrimLeft(s, marker))
+		}
+	}
+	return s
+}
+
+// isIniComment reports whether s is blank or only an inline comment
+func isIniComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == `` || s[0] == '#' || s[0] == ';' || strings.HasPrefix(s, `//`)
+}
+
+// legacyIniTokenizer returns the original regular expression based reader
+func legacyIniTokenizer() func(string) (iniLine, error) {
+	regexLine := regexp.MustCompile(strLine)
+	regexRoot := regexp.MustCompile(strRootLine)
+	regexInclude := regexp.MustCompile(strInclude)
+
+	return func(strLine string) (iniLine, error) {
+		if matches := regexLine.FindStringSubmatch(strLine); len(matches) > 0 {
+			key := strings.TrimSpace(matches[1])
+			val := strings.TrimSpace(matches[2])
+			quoted := strings.HasPrefix(val, `"`) && strings.HasSuffix(val, `"`)
+			if quoted {
+				val = val[1 : len(val)-1]
+			}
+			return iniLine{kind: iniValue, key: key, value: val, quoted: quoted}, nil
+		} else if matches := regexRoot.FindStringSubmatch(strLine); len(matches) > 0 {
+			return iniLine{kind: iniSection, section: matches[2]}, nil
+		} else if matches := regexInclude.FindStringSubmatch(strLine); len(matches) >= 2 {
+			return iniLine{kind: iniInclude, include: matches[1]}, nil
+		}
+		return iniLine{kind: iniBlank}, nil
+	}
+}
+
+// iniDescribedKey returns the property or section a comment next to line
+// describes, blank for includes
+func iniDescribedKey(root string, line iniLine) string {
+	switch line.kind {
+	case iniValue:
+		return root + "." + normalizeKey(strings.TrimSuffix(line.key, `[]`))
+	case iniSection:
+		if line.sub != `` {
+			return normalizeKey(line.section) + "." + EscapeKey(line.sub)
+		}
+		return normalizeKey(line.section)
+	}
+	return ``
+}
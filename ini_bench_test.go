@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const benchIniLines = 10000
+
+// benchIni returns lines INI lines: sections of 20 properties with a mix of
+// plain, quoted and commented values
+func benchIni(lines int, section string) string {
+	var sb strings.Builder
+	for i := 0; i < lines; i++ {
+		switch {
+		case i%20 == 0:
+			fmt.Fprintf(&sb, "[%s%d]\n", section, i/20)
+		case i%7 == 0:
+			fmt.Fprintf(&sb, "; comment for key%d\n", i)
+		case i%5 == 0:
+			fmt.Fprintf(&sb, "key%d = \"quoted value %d\" # inline\n", i, i)
+		default:
+			fmt.Fprintf(&sb, "key%d = value %d\n", i, i)
+		}
+	}
+	return sb.String()
+}
+
+func writeBenchFile(b *testing.B, path string, data string) {
+	b.Helper()
+
+	if e := os.WriteFile(path, []byte(data), 0600); e != nil {
+		b.Fatal(e)
+	}
+}
+
+// quietStdout discards what ReadIni prints for each file read
+func quietStdout(tb testing.TB) {
+	tb.Helper()
+
+	devNull, e := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if e != nil {
+		tb.Fatal(e)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	tb.Cleanup(func() {
+		os.Stdout = stdout
+		devNull.Close()
+	})
+}
+
+// benchmarkReadIni reads path, which with its includes is size bytes long
+func benchmarkReadIni(b *testing.B, path string, size int, compat bool) {
+	quietStdout(b)
+
+	b.SetBytes(int64(size))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c := &Config{storage: make(map[string]string), iniCompat: compat}
+		f := NewFile(path)
+		if e := f.ReadIni(c); e != nil {
+			b.Fatal(e)
+		}
+		if len(c.storage) == 0 {
+			b.Fatal(`no properties read`)
+		}
+	}
+}
+
+func BenchmarkReadIni10k(b *testing.B) {
+	path := filepath.Join(b.TempDir(), `app.ini`)
+	data := benchIni(benchIniLines, `section`)
+	writeBenchFile(b, path, data)
+	benchmarkReadIni(b, path, len(data), false)
+}
+
+func BenchmarkReadIni10kCompat(b *testing.B) {
+	path := filepath.Join(b.TempDir(), `app.ini`)
+	data := benchIni(benchIniLines, `section`)
+	writeBenchFile(b, path, data)
+	benchmarkReadIni(b, path, len(data), true)
+}
+
+// BenchmarkReadIni10kIncludes reads the same 10k lines split over a main
+// file and ten included files, half of them through a glob
+func BenchmarkReadIni10kIncludes(b *testing.B) {
+	dir := b.TempDir()
+	if e := os.Mkdir(filepath.Join(dir, `conf.d`), 0700); e != nil {
+		b.Fatal(e)
+	}
+
+	const parts = 10
+	per := benchIniLines / (parts + 1)
+
+	var main strings.Builder
+	main.WriteString("include conf.d/*.ini\n")
+	size := 0
+	for i := 0; i < parts; i++ {
+		name := fmt.Sprintf(`part%d.ini`, i)
+		data := benchIni(per, fmt.Sprintf(`part%d_`, i))
+		size += len(data)
+		if i%2 == 0 {
+			writeBenchFile(b, filepath.Join(dir, `conf.d`, name), data)
+		} else {
+			writeBenchFile(b, filepath.Join(dir, name), data)
+			fmt.Fprintf(&main, "include %s\n", name)
+		}
+	}
+	main.WriteString(benchIni(per, `section`))
+	size += main.Len()
+
+	path := filepath.Join(dir, `app.ini`)
+	writeBenchFile(b, path, main.String())
+	benchmarkReadIni(b, path, size, false)
+}
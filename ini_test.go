@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const iniWithBareLine = `[server]
+host = localhost
+this line has no equals sign
+port = 8080
+`
+
+func TestReadIniSkipsUnreadableLines(t *testing.T) {
+	quietStdout(t)
+
+	c := &Config{}
+	if e := c.LoadString(iniWithBareLine, `ini`); e != nil {
+		t.Fatal(e)
+	}
+	if got := c.GetString(`server.port`); got != `8080` {
+		t.Errorf(`server.port = %q, want 8080`, got)
+	}
+}
+
+func TestReadIniStrict(t *testing.T) {
+	quietStdout(t)
+
+	c := &Config{}
+	c.SetIniStrict(true)
+	e := c.LoadString(iniWithBareLine, `ini`)
+	if e == nil || !strings.Contains(e.Error(), `:3: expected key = value`) {
+		t.Errorf(`LoadString = %v, want an error for line 3`, e)
+	}
+}
+
+func TestReadIniCompat(t *testing.T) {
+	quietStdout(t)
+
+	c := &Config{}
+	c.SetIniCompat(true)
+	data := "[app]\nname = demo\n[app.db]\nhost = db\n[srv \"api\"]\nport = 1\n"
+	if e := c.LoadString(data, `ini`); e != nil {
+		t.Fatal(e)
+	}
+
+	// the original reader only knows [a-z0-9] sections, so the keys below
+	// the other ones stay in the last section it matched
+	want := map[string]string{`app.name`: `demo`, `app.host`: `db`, `app.port`: `1`}
+	for k, v := range want {
+		if got := c.GetString(k); got != v {
+			t.Errorf(`%s = %q, want %q`, k, got, v)
+		}
+	}
+}
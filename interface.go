@@ -10,6 +10,30 @@ type ConfigInterface interface {
 	// Read integer property or return defValue if property is not exists or empty
 	GetIntOr(name string, defValue int) int
 
+	// Read int64 property. If property is not exists or invalid will return 0
+	GetInt64(name string) int64
+
+	// Read int64 property or return defValue if property is not exists or invalid
+	GetInt64Or(name string, defValue int64) int64
+
+	// Read int32 property. If property is not exists or invalid will return 0
+	GetInt32(name string) int32
+
+	// Read int32 property or return defValue if property is not exists or invalid
+	GetInt32Or(name string, defValue int32) int32
+
+	// Read uint64 property. If property is not exists or invalid will return 0
+	GetUint64(name string) uint64
+
+	// Read uint64 property or return defValue if property is not exists or invalid
+	GetUint64Or(name string, defValue uint64) uint64
+
+	// Read float64 property. If property is not exists or invalid will return 0
+	GetFloat64(name string) float64
+
+	// Read float64 property or return defValue if property is not exists or invalid
+	GetFloat64Or(name string, defValue float64) float64
+
 	// Read string property
 	GetString(name string) string
 
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const jsonIncludeKey = `$include`
+
+// Read JSON config file. Nested objects and arrays are flattened into dot
+// separated keys, e.g. {"servers": [{"host": "a"}]} is stored as servers.0.host
+//
+// An object may contain "$include": "file.json" (or an array of files, globs
+// allowed) to merge other JSON files at that point; keys of the object itself
+// take precedence over included ones. Paths are relative to the including
+// file and include cycles are reported as errors.
+//
+// Comments (// and /* */, as in JSONC) are allowed; those next to a key are
+// kept as its description, see Describe.
+func (f *File) ReadJSON(c *Config) error {
+	return f.readJSON(c, ``, nil)
+}
+
+func (f *File) readJSON(c *Config, prefix string, stack []string) error {
+	abs, e := filepath.Abs(f.filename)
+	if e != nil {
+		return e
+	}
+	if contains(stack, abs) {
+		return fmt.Errorf(`%s: include cycle: %s`, f.filename, strings.Join(append(stack, abs), ` -> `))
+	}
+	stack = append(stack, abs)
+
+	data, e := f.readAll(c)
+	if e != nil {
+		return e
+	}
+
+	fmt.Println(`Read config:`, f.filename)
+	c.file = append(c.file, f.filename)
+	data = c.jsonComments(data, prefix)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil // empty layer
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if e := dec.Decode(&v); e != nil {
+		return fmt.Errorf(`%s: %v`, f.filename, e)
+	}
+
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return fmt.Errorf(`%s: top level value must be an object or array`, f.filename)
+	}
+
+	if e := f.jsonIncludes(c, prefix, v, stack); e != nil {
+		return e
+	}
+
+	flatten(prefix, v, c.storage)
+	return nil
+}
+
+// jsonIncludes reads the files named by $include keys found in v and removes
+// those keys
+func (f *File) jsonIncludes(c *Config, prefix string, v interface{}, stack []string) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if inc, ok := val[jsonIncludeKey]; ok {
+			delete(val, jsonIncludeKey)
+
+			var names []string
+			switch files := inc.(type) {
+			case string:
+				names = []string{files}
+			case []interface{}:
+				for _, name := range files {
+					s, ok := name.(string)
+					if !ok {
+						return fmt.Errorf(`%s: %s must list file names`, f.filename, jsonIncludeKey)
+					}
+					names = append(names, s)
+				}
+			default:
+				return fmt.Errorf(`%s: %s must be a file name or an array of file names`, f.filename, jsonIncludeKey)
+			}
+
+			for _, name := range names {
+				paths, e := f.includePaths(c, name)
+				if e != nil {
+					return e
+				}
+				for _, path := range paths {
+					inc := NewFile(path)
+					if e := inc.readJSON(c, prefix, stack); e != nil {
+						return e
+					}
+				}
+			}
+		}
+
+		for k, child := range val {
+			if e := f.jsonIncludes(c, joinKey(prefix, EscapeKey(k)), child, stack); e != nil {
+				return e
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			if e := f.jsonIncludes(c, joinKey(prefix, strconv.Itoa(i)), child, stack); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// jsonComments blanks the // and /* */ comments of JSONC data, so it can be
+// decoded as JSON, and describes the keys they belong to: the comment lines
+// right above a key or a comment after a value on the same line.
+func (c *Config) jsonComments(data []byte, prefix string) []byte {
+	if !bytes.Contains(data, []byte("/")) {
+		return data
+	}
+	out := append([]byte(nil), data...)
+
+	type frame struct {
+		obj       bool
+		expectKey bool
+		key       string
+		idx       int
+	}
+	var stack []frame
+	keyPath := func(key string) string {
+		p := prefix
+		for _, f := range stack[:len(stack)-1] {
+			if f.obj {
+				p = joinKey(p, EscapeKey(f.key))
+			} else {
+				p = joinKey(p, strconv.Itoa(f.idx))
+			}
+		}
+		return joinKey(p, EscapeKey(key))
+	}
+
+	type description struct {
+		above  []string
+		inline string
+	}
+	descriptions := make(map[string]*description)
+	var above []string
+	lineKey := ``     // key of the value on the current line
+	lineEmpty := true // nothing but whitespace on the current line
+
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+		switch {
+		case ch == '\n':
+			if lineEmpty {
+				above = nil // a blank line detaches the comments
+			}
+			lineKey, lineEmpty = ``, true
+		case ch == ' ' || ch == '\t' || ch == '\r':
+		case ch == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			end := bytes.IndexByte(data[i:], '\n')
+			if data[i+1] == '*' {
+				end = bytes.Index(data[i+2:], []byte("*/"))
+				if end >= 0 {
+					end += 4
+				}
+			}
+			if end < 0 {
+				end = len(data) - i
+			}
+
+			var lines []string
+			for _, l := range strings.Split(string(data[i:i+end]), "\n") {
+				l = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(l), `*/`))
+				l = strings.TrimSpace(strings.TrimLeft(strings.TrimPrefix(l, `/*`), `*`))
+				if l = commentText(l); l != `` {
+					lines = append(lines, l)
+				}
+			}
+			for j := i; j < i+end; j++ {
+				if out[j] != '\n' {
+					out[j] = ' '
+				}
+			}
+
+			if lineKey != `` {
+				descriptions[lineKey].inline = strings.Join(lines, "\n")
+			} else {
+				above = append(above, lines...)
+			}
+			lineEmpty = false
+			i += end - 1
+		case ch == '"':
+			end := i + 1
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(data) {
+				return out // let the decoder report the error
+			}
+
+			if n := len(stack); n > 0 && stack[n-1].obj && stack[n-1].expectKey {
+				var key string
+				if json.Unmarshal(data[i:end+1], &key) == nil {
+					stack[n-1].key = key
+					lineKey = keyPath(key)
+					descriptions[lineKey] = &description{above: above}
+					above = nil
+				}
+			}
+			lineEmpty = false
+			i = end
+		default:
+			n := len(stack)
+			switch ch {
+			case '{', '[':
+				stack = append(stack, frame{obj: ch == '{', expectKey: ch == '{'})
+			case '}', ']':
+				if n > 0 {
+					stack = stack[:n-1]
+				}
+			case ':':
+				if n > 0 {
+					stack[n-1].expectKey = false
+				}
+			case ',':
+				if n > 0 {
+					stack[n-1].expectKey = stack[n-1].obj
+					stack[n-1].idx++
+				}
+			}
+			lineEmpty = false
+		}
+	}
+
+	for key, d := range descriptions {
+		c.describeKey(key, d.above, d.inline)
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package config
+
+import "strings"
+
+// Escape dots, brackets and backslashes in a single key part so it can be
+// joined with other parts, e.g. EscapeKey("app.kubernetes.io/name") returns
+// `app\.kubernetes\.io/name`.
+func EscapeKey(part string) string {
+	if !strings.ContainsAny(part, `.[\`) {
+		return part
+	}
+	var b strings.Builder
+	for i := 0; i < len(part); i++ {
+		switch part[i] {
+		case '.', '[', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(part[i])
+	}
+	return b.String()
+}
+
+// Split a property name into its parts. Dots inside a part are written as
+// `\.` or with the bracket syntax, so `labels.app\.kubernetes\.io/name` and
+// `labels["app.kubernetes.io/name"]` both return [labels app.kubernetes.io/name].
+func SplitKey(key string) []string {
+	var parts []string
+	var cur strings.Builder
+	pending := true
+
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		switch {
+		case ch == '\\' && i+1 < len(key):
+			i++
+			cur.WriteByte(key[i])
+			pending = true
+		case ch == '.':
+			parts = append(parts, cur.String())
+			cur.Reset()
+			pending = true
+		case ch == '[':
+			part, next, ok := bracketPart(key, i)
+			if !ok {
+				cur.WriteByte(ch)
+				pending = true
+				continue
+			}
+			if cur.Len() > 0 {
+				parts = append(parts, cur.String())
+				cur.Reset()
+			}
+			parts = append(parts, part)
+			pending = false
+			i = next
+			if i+1 < len(key) && key[i+1] == '.' {
+				i++
+				pending = true
+			}
+		default:
+			cur.WriteByte(ch)
+			pending = true
+		}
+	}
+	if pending || cur.Len() > 0 {
+		parts = append(parts, cur.String())
+	}
+	return parts
+}
+
+// Join key parts escaping each of them
+func JoinKey(parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, p := range parts {
+		escaped[i] = EscapeKey(p)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// bracketPart parses ["..."], ['...'] or [...] starting at key[start] and
+// returns the part and the index of the closing bracket.
+func bracketPart(key string, start int) (string, int, bool) {
+	rest := key[start+1:]
+	if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+		quote := rest[0]
+		end := strings.IndexByte(rest[1:], quote)
+		if end < 0 || len(rest) < end+3 || rest[end+2] != ']' {
+			return ``, 0, false
+		}
+		return rest[1 : end+1], start + end + 3, true
+	}
+
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return ``, 0, false
+	}
+	return rest[:end], start + end + 1, true
+}
+
+// normalizeKey rewrites bracket syntax into the escaped dot form used by storage
+func normalizeKey(name string) string {
+	if !strings.ContainsAny(name, `[\`) {
+		return name
+	}
+	return JoinKey(SplitKey(name)...)
+}
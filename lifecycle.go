@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// State is the lifecycle state of a Config: New, then Opened, optionally
+// Watching, and finally Closed
+type State int
+
+const (
+	StateNew      State = iota // no source opened yet
+	StateOpened                // sources read, not watched
+	StateWatching              // sources read and watched for changes
+	StateClosed                // Close was called, properties stay readable
+)
+
+var (
+	// ErrNotOpened is returned by APIs needing sources before Open
+	ErrNotOpened = errors.New(`Config is not opened`)
+	// ErrClosed is returned by APIs reading or watching sources after Close
+	ErrClosed = errors.New(`Config is closed`)
+)
+
+func (s State) String() string {
+	switch s {
+	case StateNew:
+		return `new`
+	case StateOpened:
+		return `opened`
+	case StateWatching:
+		return `watching`
+	case StateClosed:
+		return `closed`
+	}
+	return fmt.Sprintf(`State(%d)`, int(s))
+}
+
+// Return the lifecycle state
+func (c *Config) State() State {
+	if c.parent != nil {
+		return c.parent.State()
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.state()
+}
+
+// state returns the lifecycle state. Caller must hold c.mu.
+func (c *Config) state() State {
+	switch {
+	case c.closed:
+		return StateClosed
+	case c.watching:
+		return StateWatching
+	case len(c.sources) > 0 || c.file != nil:
+		return StateOpened
+	}
+	return StateNew
+}
+
+// checkOpen returns ErrClosed after Close and ErrNotOpened before any source
+// was opened
+func (c *Config) checkOpen() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch {
+	case c.closed:
+		return ErrClosed
+	case len(c.sources) == 0:
+		return ErrNotOpened
+	}
+	return nil
+}
+
+// checkNotClosed returns ErrClosed after Close
+func (c *Config) checkNotClosed() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+// notFound returns ErrKeyNotFound for name, explaining when the Config was
+// never opened
+func (c *Config) notFound(name string) error {
+	if c.State() == StateNew {
+		return fmt.Errorf(`%w: %s (%v)`, ErrKeyNotFound, name, ErrNotOpened)
+	}
+	return fmt.Errorf(`%w: %s`, ErrKeyNotFound, name)
+}
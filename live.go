@@ -0,0 +1,73 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Live keeps a decoded copy of the config in a struct of type T and swaps it
+// atomically whenever the config is reloaded.
+type Live[T any] struct {
+	value   atomic.Pointer[T]
+	changes chan *T
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// Decode cfg into a new T and keep it up to date on every Reload
+func NewLive[T any](cfg *Config) (*Live[T], error) {
+	v := new(T)
+	if e := cfg.MapToStructNested(v); e != nil {
+		return nil, e
+	}
+
+	l := &Live[T]{changes: make(chan *T, 1)}
+	l.value.Store(v)
+
+	cfg.OnReload(func() {
+		l.update(cfg)
+	})
+	return l, nil
+}
+
+// Return the latest decoded value. The returned value must not be modified.
+func (l *Live[T]) Get() *T {
+	return l.value.Load()
+}
+
+// Return channel receiving the new value after every reload that changed it.
+// Slow readers only see the most recent value.
+func (l *Live[T]) Changes() <-chan *T {
+	return l.changes
+}
+
+// Return the error of the last failed decode, nil after a successful one.
+// On failure Get keeps returning the previous value.
+func (l *Live[T]) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lastErr
+}
+
+func (l *Live[T]) update(cfg *Config) {
+	v := new(T)
+	e := cfg.MapToStructNested(v)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastErr = e
+	if e != nil || reflect.DeepEqual(v, l.value.Load()) {
+		return
+	}
+	l.value.Store(v)
+
+	select {
+	case <-l.changes:
+	default:
+	}
+	l.changes <- v
+}
@@ -0,0 +1,66 @@
+package config
+
+import "strings"
+
+// MapOptions controls how LoadMap turns keys into property names
+type MapOptions struct {
+	// Only keys starting with Prefix are loaded, the prefix is removed
+	Prefix string
+
+	// Separator between key parts, default "__" (DB__HOST becomes db.host)
+	Separator string
+
+	// KeyTransformer replaces the default transformation when set
+	KeyTransformer func(key string) string
+}
+
+type mapLayer struct {
+	storage map[string]string
+}
+
+// Merge a flat key/value map (e.g. injected environment or legacy property
+// maps) on top of the config files. The layer is kept and applied again after
+// every Reload; layers loaded later take precedence, runtime overrides made
+// with Set win over all of them.
+func (c *Config) LoadMap(m map[string]string, opts MapOptions) {
+	layer := mapLayer{storage: make(map[string]string, len(m))}
+	for k, v := range m {
+		if !strings.HasPrefix(k, opts.Prefix) {
+			continue
+		}
+		layer.storage[opts.transform(strings.TrimPrefix(k, opts.Prefix))] = v
+	}
+	if c.parent != nil {
+		c.parent.LoadMap(c.subKeys(layer.storage), MapOptions{KeyTransformer: func(key string) string { return key }})
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.layers = append(c.layers, layer)
+	if c.storage == nil {
+		c.storage = make(map[string]string)
+	}
+	mergeLayer(c.storage, layer.storage, c.arrayMerge)
+	c.applyOverrides(c.storage)
+}
+
+func (o MapOptions) transform(key string) string {
+	if o.KeyTransformer != nil {
+		return o.KeyTransformer(key)
+	}
+
+	sep := o.Separator
+	if sep == `` {
+		sep = `__`
+	}
+	return strings.ToLower(strings.ReplaceAll(key, sep, "."))
+}
+
+// applyLayers copies the LoadMap layers into storage in load order
+func (c *Config) applyLayers(storage map[string]string) {
+	for _, layer := range c.layers {
+		mergeLayer(storage, layer.storage, c.arrayMerge)
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// numberFormat holds the separators set with SetNumberFormat
+type numberFormat struct {
+	decimal   rune
+	thousands rune
+}
+
+// Read float properties written with locale separators, e.g.
+// SetNumberFormat(',', '.') reads "1.234,56" as 1234.56. thousands may be 0
+// when numbers are not grouped. Applies to GetFloat64, GetFloat32 and float
+// fields filled by MapToStructNested. SetNumberFormat('.', 0) restores the
+// default syntax.
+func (c *Config) SetNumberFormat(decimal rune, thousands rune) error {
+	if decimal == 0 || decimal == thousands {
+		return errors.New(`config: invalid number format`)
+	}
+	if strings.ContainsRune(`+-0123456789eE`, decimal) || strings.ContainsRune(`+-0123456789eE`, thousands) {
+		return errors.New(`config: invalid number format`)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if decimal == '.' && thousands == 0 {
+		c.numbers = nil
+		return nil
+	}
+	c.numbers = &numberFormat{decimal: decimal, thousands: thousands}
+	return nil
+}
+
+// parseFloat parses s written with the separators of f, a nil f accepts the
+// Go syntax only
+func (f *numberFormat) parseFloat(s string, bitSize int) (float64, error) {
+	if f == nil {
+		return strconv.ParseFloat(s, bitSize)
+	}
+
+	n, ok := f.normalize(s)
+	if !ok {
+		return 0, &strconv.NumError{Func: `ParseFloat`, Num: s, Err: strconv.ErrSyntax}
+	}
+	v, e := strconv.ParseFloat(n, bitSize)
+	if e != nil {
+		// report the value as written
+		return v, &strconv.NumError{Func: `ParseFloat`, Num: s, Err: errors.Unwrap(e)}
+	}
+	return v, nil
+}
+
+// normalize removes thousands separators and turns the decimal separator
+// into a dot. Groups after a thousands separator must have three digits and
+// a dot that is neither separator makes s invalid, so "1.5" is not read as 15.
+func (f *numberFormat) normalize(s string) (string, bool) {
+	var b strings.Builder
+	group := -1 // digits since the last thousands separator
+	for _, r := range s {
+		switch {
+		case f.thousands != 0 && r == f.thousands:
+			if group == 0 || group > 0 && group != 3 {
+				return ``, false
+			}
+			group = 0
+			continue
+		case r == f.decimal:
+			b.WriteByte('.')
+		case r == '.':
+			return ``, false
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+			if group >= 0 {
+				group++
+			}
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		if group >= 0 && group != 3 {
+			return ``, false
+		}
+		group = -1
+	}
+	if group >= 0 && group != 3 {
+		return ``, false
+	}
+	return b.String(), true
+}
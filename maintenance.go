@@ -0,0 +1,232 @@
+package config
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceSwitch turns maintenance mode on and off from the config. Set,
+// Update, Reload and watched files switch it without a restart; the windows
+// are parsed once and again whenever they change.
+//
+//	[app]
+//	maintenance = false
+//	maintenance_windows[] = 2024-06-01T02:00:00Z/2024-06-01T04:00:00Z
+//	maintenance_message = Back at 04:00 UTC
+type MaintenanceSwitch struct {
+	c       *Config
+	key     string
+	windows string
+	message string
+
+	mu       sync.Mutex
+	active   bool
+	handlers []func(active bool)
+	timer    *time.Timer
+	watching bool
+	stopped  bool
+
+	windowsOnce sync.Once
+	windowsList atomic.Value // []maintenanceWindow
+}
+
+type maintenanceWindow struct {
+	start, end time.Time
+}
+
+// Return a switch on the boolean property key (e.g. app.maintenance). It is
+// also on during the start/end windows of key + "_windows", a single value
+// or an array of RFC3339 intervals.
+func (c *Config) MaintenanceSwitch(key string) *MaintenanceSwitch {
+	if c.parent != nil {
+		return c.parent.MaintenanceSwitch(c.subPrefix + normalizeKey(key))
+	}
+
+	key = normalizeKey(key)
+	return &MaintenanceSwitch{
+		c:       c,
+		key:     key,
+		windows: key + `_windows`,
+		message: key + `_message`,
+	}
+}
+
+// Return true when maintenance mode is on
+func (m *MaintenanceSwitch) Active() bool {
+	on, _ := m.state()
+	return on
+}
+
+// state returns whether maintenance is on and when it ends, zero when the
+// end is unknown
+func (m *MaintenanceSwitch) state() (bool, time.Time) {
+	c := m.c
+	c.mu.RLock()
+	now := c.now()
+	c.mu.RUnlock()
+
+	for _, w := range m.currentWindows() {
+		if !now.Before(w.start) && now.Before(w.end) {
+			return true, w.end
+		}
+	}
+
+	on, _ := strconv.ParseBool(strings.TrimSpace(c.GetString(m.key)))
+	return on, time.Time{}
+}
+
+// currentWindows returns the windows parsed on first use and again whenever
+// a property under the windows key changes, so requests do not copy the
+// whole config
+func (m *MaintenanceSwitch) currentWindows() []maintenanceWindow {
+	m.windowsOnce.Do(func() {
+		m.windowsList.Store(m.parseWindows())
+		m.c.WatchPrefix(m.windows, func([]Change) {
+			m.windowsList.Store(m.parseWindows())
+		})
+	})
+	return m.windowsList.Load().([]maintenanceWindow)
+}
+
+// parseWindows returns the valid windows sorted by start. Invalid windows
+// are ignored.
+func (m *MaintenanceSwitch) parseWindows() []maintenanceWindow {
+	c := m.c
+	c.mu.RLock()
+	loc := c.location
+	layouts := c.timeLayouts
+	c.mu.RUnlock()
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var values []string
+	if v, ok := c.lookup(m.windows); ok {
+		values = append(values, v)
+	}
+	for k, v := range c.GetAll() {
+		if strings.HasPrefix(k, m.windows+`.`) {
+			values = append(values, v)
+		}
+	}
+
+	var out []maintenanceWindow
+	for _, v := range values {
+		from, to, ok := strings.Cut(v, `/`)
+		if !ok {
+			continue
+		}
+		start, e1 := parseTime(strings.TrimSpace(from), loc, layouts...)
+		end, e2 := parseTime(strings.TrimSpace(to), loc, layouts...)
+		if e1 == nil && e2 == nil && end.After(start) {
+			out = append(out, maintenanceWindow{start: start, end: end})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].start.Before(out[j].start) })
+	return out
+}
+
+// Wrap next with a handler answering 503 Service Unavailable while
+// maintenance mode is on. The body is key + "_message" when set, and
+// Retry-After is sent during a window.
+func (m *MaintenanceSwitch) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		on, end := m.state()
+		if !on {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !end.IsZero() {
+			m.c.mu.RLock()
+			wait := end.Sub(m.c.now())
+			m.c.mu.RUnlock()
+			w.Header().Set(`Retry-After`, strconv.Itoa(int(wait.Seconds())+1))
+		}
+		msg := m.c.GetStringOr(m.message, `Service under maintenance`)
+		http.Error(w, msg, http.StatusServiceUnavailable)
+	})
+}
+
+// Call fn with the new state whenever maintenance mode turns on or off,
+// from a config change or a window starting or ending
+func (m *MaintenanceSwitch) OnChange(fn func(active bool)) {
+	m.mu.Lock()
+	m.handlers = append(m.handlers, fn)
+	start := !m.watching
+	m.watching = true
+	m.mu.Unlock()
+
+	if start {
+		on := m.Active()
+		m.mu.Lock()
+		m.active = on
+		m.mu.Unlock()
+
+		m.c.WatchPrefix(m.key, func([]Change) { m.check() })
+		m.schedule()
+	}
+}
+
+// check calls the handlers when the state changed since the last check
+func (m *MaintenanceSwitch) check() {
+	on := m.Active()
+
+	m.mu.Lock()
+	changed := on != m.active
+	m.active = on
+	handlers := append(make([]func(bool), 0, len(m.handlers)), m.handlers...)
+	m.mu.Unlock()
+
+	if changed {
+		for _, fn := range handlers {
+			fn(on)
+		}
+	}
+	m.schedule()
+}
+
+// schedule arms the timer for the next window start or end
+func (m *MaintenanceSwitch) schedule() {
+	m.c.mu.RLock()
+	now := m.c.now()
+	m.c.mu.RUnlock()
+
+	var next time.Time
+	for _, w := range m.currentWindows() {
+		for _, t := range []time.Time{w.start, w.end} {
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	if !next.IsZero() && !m.stopped {
+		m.timer = time.AfterFunc(next.Sub(now), m.check)
+	}
+}
+
+// Stop the timer of the windows. Handlers are no longer called for window
+// boundaries, but still for config changes.
+func (m *MaintenanceSwitch) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stopped = true
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
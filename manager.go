@@ -0,0 +1,168 @@
+package config
+
+import (
+	"container/list"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ConfigManager opens one Config per tenant on first access and keeps at
+// most capacity of them, closing the least recently used ones.
+type ConfigManager struct {
+	mu       sync.Mutex
+	files    func(tenant string) []string
+	capacity int
+	watch    bool
+
+	tenants map[string]*list.Element
+	lru     *list.List
+}
+
+type tenantConfig struct {
+	name  string
+	ready chan struct{}
+	cfg   *Config
+	err   error
+}
+
+// Create manager that reads the config files returned by files for each
+// tenant. A capacity of 0 or less keeps every opened tenant.
+func NewConfigManager(files func(tenant string) []string, capacity int) *ConfigManager {
+	return &ConfigManager{
+		files:    files,
+		capacity: capacity,
+		tenants:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Watch the config files of every opened tenant. All tenants share the
+// package watcher. Call before the first ForTenant.
+func (m *ConfigManager) SetWatch(watch bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.watch = watch
+}
+
+// Return the config of tenant, opening it on first access
+func (m *ConfigManager) ForTenant(tenant string) (*Config, error) {
+	if tenant == `` || tenant == `.` || tenant == `..` || strings.ContainsAny(tenant, `/\`) {
+		return nil, errors.New(`invalid tenant name: ` + tenant)
+	}
+
+	m.mu.Lock()
+	if el, ok := m.tenants[tenant]; ok {
+		m.lru.MoveToFront(el)
+		t := el.Value.(*tenantConfig)
+		m.mu.Unlock()
+
+		<-t.ready
+		return t.cfg, t.err
+	}
+
+	t := &tenantConfig{name: tenant, ready: make(chan struct{})}
+	m.tenants[tenant] = m.lru.PushFront(t)
+	watch := m.watch
+	m.mu.Unlock()
+
+	cfg := new(Config)
+	t.err = cfg.Open(m.files(tenant)...)
+	if t.err == nil && watch {
+		t.err = cfg.Watch()
+	}
+	if t.err != nil {
+		m.forget(t)
+		close(t.ready)
+		return nil, t.err
+	}
+	t.cfg = cfg
+	close(t.ready)
+
+	m.mu.Lock()
+	evicted := m.evictLocked(t)
+	m.mu.Unlock()
+
+	for _, old := range evicted {
+		closeTenant(old)
+	}
+	return cfg, nil
+}
+
+// Close and forget the config of tenant
+func (m *ConfigManager) Evict(tenant string) {
+	m.mu.Lock()
+	el, ok := m.tenants[tenant]
+	if ok {
+		m.lru.Remove(el)
+		delete(m.tenants, tenant)
+	}
+	m.mu.Unlock()
+
+	if ok {
+		closeTenant(el.Value.(*tenantConfig))
+	}
+}
+
+// Return the names of opened tenants, most recently used first
+func (m *ConfigManager) Tenants() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, m.lru.Len())
+	for el := m.lru.Front(); el != nil; el = el.Next() {
+		names = append(names, el.Value.(*tenantConfig).name)
+	}
+	return names
+}
+
+// Close every opened tenant config
+func (m *ConfigManager) Close() error {
+	m.mu.Lock()
+	var all []*tenantConfig
+	for el := m.lru.Front(); el != nil; el = el.Next() {
+		all = append(all, el.Value.(*tenantConfig))
+	}
+	m.tenants = make(map[string]*list.Element)
+	m.lru.Init()
+	m.mu.Unlock()
+
+	for _, t := range all {
+		closeTenant(t)
+	}
+	return nil
+}
+
+// evictLocked removes the least recently used tenants above capacity, except keep
+func (m *ConfigManager) evictLocked(keep *tenantConfig) []*tenantConfig {
+	var evicted []*tenantConfig
+	for el := m.lru.Back(); el != nil && m.capacity > 0 && m.lru.Len() > m.capacity; {
+		prev := el.Prev()
+		if t := el.Value.(*tenantConfig); t != keep {
+			m.lru.Remove(el)
+			delete(m.tenants, t.name)
+			evicted = append(evicted, t)
+		}
+		el = prev
+	}
+	return evicted
+}
+
+func (m *ConfigManager) forget(t *tenantConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.tenants[t.name]; ok && el.Value == t {
+		m.lru.Remove(el)
+		delete(m.tenants, t.name)
+	}
+}
+
+// closeTenant waits for a pending open before closing the config
+func closeTenant(t *tenantConfig) {
+	<-t.ready
+	if t.cfg != nil {
+		t.cfg.Close()
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ArrayMerge selects how arrays (key.0, key.1, ...) defined by several config
+// files or LoadMap layers are combined
+type ArrayMerge int
+
+const (
+	// A later layer defining an array replaces the whole array, e.g. base
+	// servers.0..2 and overlay servers.0 result in servers.0 only
+	ArrayReplace ArrayMerge = iota
+
+	// Elements are merged by index, e.g. base servers.0..2 and overlay
+	// servers.0 result in servers.0 from overlay and servers.1..2 from base
+	ArrayMergeIndex
+)
+
+// Set how arrays from different layers are combined. Default ArrayReplace.
+func (c *Config) SetArrayMerge(mode ArrayMerge) {
+	if c.parent != nil {
+		c.parent.SetArrayMerge(mode)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.arrayMerge = mode
+}
+
+// mergeLayer copies layer into storage applying the array merge mode. With
+// ArrayReplace an array is replaced only when storage holds an array at the
+// same name too, so maps with numeric keys (codes.404) are still merged.
+func mergeLayer(storage map[string]string, layer map[string]string, mode ArrayMerge) {
+	if mode == ArrayReplace {
+		prefixes := arrayPrefixes(layer)
+		if len(prefixes) > 0 {
+			existing := arrayPrefixes(storage)
+			for p := range prefixes {
+				if !existing[p] {
+					delete(prefixes, p)
+				}
+			}
+		}
+		if len(prefixes) > 0 {
+			for k := range storage {
+				for p := range prefixes {
+					if strings.HasPrefix(k, p) {
+						delete(storage, k)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	for k, v := range layer {
+		storage[k] = v
+	}
+}
+
+// arrayPrefixes returns "name." for every array name in storage: a name
+// whose children are exactly 0, 1, ..., n-1
+func arrayPrefixes(storage map[string]string) map[string]bool {
+	children := make(map[string]map[string]bool)
+	for k := range storage {
+		parts := SplitKey(k)
+		for i := 1; i < len(parts); i++ {
+			p := JoinKey(parts[:i]...) + "."
+			if children[p] == nil {
+				children[p] = make(map[string]bool)
+			}
+			children[p][parts[i]] = true
+		}
+	}
+
+	prefixes := make(map[string]bool)
+	for p, parts := range children {
+		array := true
+		for i := 0; i < len(parts); i++ {
+			if !parts[strconv.Itoa(i)] {
+				array = false
+				break
+			}
+		}
+		if array {
+			prefixes[p] = true
+		}
+	}
+	return prefixes
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Migration renames property From, and the properties under it, to To.
+// Configs still using From keep working with a warning until Deadline.
+type Migration struct {
+	From string
+	To   string
+	// Transform converts the old value, e.g. seconds to a duration. Nil
+	// keeps the value.
+	Transform func(value string) string
+	// Deadline after which From is no longer migrated, zero for never
+	Deadline time.Time
+	// Rewrite also renames From in the overrides file whenever it is saved
+	Rewrite bool
+}
+
+// Register key migrations applied on every load. The old keys are removed
+// and their values copied to the new keys unless those are set too. A
+// warning is printed for every migrated key.
+func (c *Config) AddMigrations(migrations ...Migration) {
+	if c.parent != nil {
+		prefixed := make([]Migration, len(migrations))
+		for i, m := range migrations {
+			m.From = c.subPrefix + normalizeKey(m.From)
+			m.To = c.subPrefix + normalizeKey(m.To)
+			prefixed[i] = m
+		}
+		c.parent.AddMigrations(prefixed...)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	all := append([]Migration(nil), c.migrations...)
+	for _, m := range migrations {
+		m.From = normalizeKey(m.From)
+		m.To = normalizeKey(m.To)
+		all = append(all, m)
+	}
+	c.migrations = all
+	if c.storage != nil {
+		c.applyMigrations(c.storage)
+	}
+}
+
+// applyMigrations renames the old keys in storage. Caller must hold c.mu.
+func (c *Config) applyMigrations(storage map[string]string) {
+	now := c.now()
+	for _, m := range c.migrations {
+		renamed := migrateKeys(storage, m)
+		if len(renamed) == 0 {
+			continue
+		}
+
+		if !m.Deadline.IsZero() && now.After(m.Deadline) {
+			fmt.Println(`Config key removed:`, m.From, `is no longer migrated to`, m.To,
+				`since`, m.Deadline.Format(`2006-01-02`))
+			continue
+		}
+
+		for old, key := range renamed {
+			val := storage[old]
+			delete(storage, old)
+			if _, ok := storage[key]; ok {
+				continue
+			}
+			if m.Transform != nil {
+				val = m.Transform(val)
+			}
+			storage[key] = val
+		}
+
+		msg := `Config key deprecated: ` + m.From + ` is renamed to ` + m.To
+		if !m.Deadline.IsZero() {
+			msg += `, removed after ` + m.Deadline.Format(`2006-01-02`)
+		}
+		fmt.Println(msg)
+	}
+}
+
+// migrateKeys returns the keys of storage m renames, mapped to their new
+// names
+func migrateKeys(storage map[string]string, m Migration) map[string]string {
+	renamed := make(map[string]string)
+	for k := range storage {
+		if k == m.From {
+			renamed[k] = m.To
+		} else if strings.HasPrefix(k, m.From+`.`) {
+			renamed[k] = m.To + strings.TrimPrefix(k, m.From)
+		}
+	}
+	return renamed
+}
+
+// rewriteOverrides renames the keys of the migrations with Rewrite in
+// overrides, in place. Caller must hold c.mu.
+func (c *Config) rewriteOverrides(overrides map[string]string) {
+	for _, m := range c.migrations {
+		if !m.Rewrite {
+			continue
+		}
+
+		for old, key := range migrateKeys(overrides, m) {
+			val := overrides[old]
+			delete(overrides, old)
+			if _, ok := overrides[key]; !ok {
+				if m.Transform != nil {
+					val = m.Transform(val)
+				}
+				overrides[key] = val
+			}
+		}
+	}
+}
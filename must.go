@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Read string property, panics if property is not exists
+func (c *Config) MustGetString(name string) string {
+	v, e := c.GetStringE(name)
+	c.must(name, e)
+	return v
+}
+
+// Read integer property, panics if property is not exists or invalid
+func (c *Config) MustGetInt(name string) int {
+	v, e := c.GetIntE(name)
+	c.must(name, e)
+	return v
+}
+
+// Read int64 property, panics if property is not exists or invalid
+func (c *Config) MustGetInt64(name string) int64 {
+	v, e := c.GetInt64E(name)
+	c.must(name, e)
+	return v
+}
+
+// Read float64 property, panics if property is not exists or invalid
+func (c *Config) MustGetFloat64(name string) float64 {
+	v, e := c.GetFloat64E(name)
+	c.must(name, e)
+	return v
+}
+
+// Read boolean property, panics if property is not exists or invalid
+func (c *Config) MustGetBool(name string) bool {
+	v, e := c.GetBoolE(name)
+	c.must(name, e)
+	return v
+}
+
+// Read duration property, panics if property is not exists or invalid
+func (c *Config) MustGetDuration(name string) time.Duration {
+	v, e := c.GetDurationE(name)
+	c.must(name, e)
+	return v
+}
+
+// Read string slice property, panics if property is not exists
+func (c *Config) MustGetStringSlice(name string) []string {
+	v, e := c.GetStringSliceE(name)
+	c.must(name, e)
+	return v
+}
+
+// must panics with e, naming where property name was looked up or read from
+func (c *Config) must(name string, e error) {
+	if e == nil {
+		return
+	}
+
+	if errors.Is(e, ErrKeyNotFound) {
+		root, prefix := c.root()
+		name = prefix + name
+		root.mu.RLock()
+		files := strings.Join(root.file, `, `)
+		root.mu.RUnlock()
+		if files == `` {
+			panic(fmt.Sprintf(`config: required property %s is missing`, normalizeKey(name)))
+		}
+		panic(fmt.Sprintf(`config: required property %s is missing from %s`, normalizeKey(name), files))
+	}
+
+	if origin := c.Origin(name); origin != `` {
+		panic(fmt.Sprintf(`%v (set by %s)`, e, origin))
+	}
+	panic(e.Error())
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNamespaceTaken is returned by RegisterNamespace when the prefix overlaps
+// a namespace registered before
+var ErrNamespaceTaken = errors.New(`config: namespace already registered`)
+
+// Namespace is a key prefix reserved by a library sharing a Config. Names
+// passed to its methods are relative to the prefix.
+type Namespace struct {
+	c          *Config
+	prefix     string
+	validators []func(c *Config) error
+}
+
+// ValidationError lists the problems found by Namespace.Validate and Update
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = fmt.Sprintf(`%s: %v`, p.Key, p.Err)
+		if p.Key == `` {
+			msgs[i] = p.Err.Error()
+		}
+	}
+	return strings.Join(msgs, `; `)
+}
+
+// Reserve the keys under prefix (e.g. "mylib.") for a library. Registering
+// a prefix equal to, inside or around an existing namespace fails with
+// ErrNamespaceTaken.
+func (c *Config) RegisterNamespace(prefix string) (*Namespace, error) {
+	if c.parent != nil {
+		return c.parent.RegisterNamespace(c.subPrefix + normalizeKey(prefix))
+	}
+
+	prefix = strings.TrimSuffix(normalizeKey(prefix), ".")
+	if prefix == `` {
+		return nil, errors.New(`config: empty namespace`)
+	}
+	prefix += "."
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, ns := range c.namespaces {
+		if strings.HasPrefix(prefix, ns.prefix) || strings.HasPrefix(ns.prefix, prefix) {
+			return nil, fmt.Errorf(`%w: %s overlaps %s`, ErrNamespaceTaken, prefix, ns.prefix)
+		}
+	}
+
+	ns := &Namespace{c: c, prefix: prefix}
+	c.namespaces = append(c.namespaces, ns)
+	return ns, nil
+}
+
+// Return the reserved prefix, ending with a dot
+func (n *Namespace) Prefix() string {
+	return n.prefix
+}
+
+// Return the full property name of name
+func (n *Namespace) Key(name string) string {
+	return n.prefix + normalizeKey(name)
+}
+
+// Set the value used when no source sets the property. Defaults have the
+// lowest precedence and survive Reload.
+func (n *Namespace) SetDefault(name string, value string) {
+	key := n.Key(name)
+
+	n.c.mu.Lock()
+	defer n.c.mu.Unlock()
+
+	if n.c.defaults == nil {
+		n.c.defaults = make(map[string]string)
+	}
+	n.c.defaults[key] = value
+	if n.c.storage == nil {
+		n.c.storage = make(map[string]string)
+	}
+	if _, ok := n.c.storage[key]; !ok {
+		n.c.storage[key] = value
+	}
+}
+
+// Declare the type of a property of the namespace, see Config.DeclareType
+func (n *Namespace) DeclareType(name string, kind Kind) error {
+	return n.c.DeclareType(n.Key(name), kind)
+}
+
+// Mark properties of the namespace that must be set
+func (n *Namespace) Require(names ...string) {
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = n.Key(name)
+	}
+	n.c.Require(keys...)
+}
+
+// Register function checking the namespace, run by Validate and SelfTest
+func (n *Namespace) AddValidator(fn func(c *Config) error) {
+	n.c.mu.Lock()
+	defer n.c.mu.Unlock()
+
+	n.validators = append(n.validators, fn)
+}
+
+// Check the current properties of the namespace only: required properties,
+// declared types and validators. Returns a *ValidationError.
+func (n *Namespace) Validate() error {
+	c := n.c
+
+	c.mu.RLock()
+	var required []string
+	for _, name := range c.required {
+		if strings.HasPrefix(name, n.prefix) {
+			required = append(required, name)
+		}
+	}
+	validators := append([]func(*Config) error(nil), n.validators...)
+	c.mu.RUnlock()
+
+	var problems []Problem
+	sort.Strings(required)
+	for _, name := range required {
+		if _, ok := c.lookupRaw(name); !ok {
+			problems = append(problems, Problem{Check: `required`, Key: name, Err: ErrKeyNotFound})
+		}
+	}
+	for _, g := range c.ExplainTypes() {
+		if g.Invalid && strings.HasPrefix(g.Key, n.prefix) {
+			problems = append(problems, Problem{Check: `type`, Key: g.Key, Err: c.invalidType(g)})
+		}
+	}
+	for _, fn := range validators {
+		if e := fn(c); e != nil {
+			problems = append(problems, Problem{Check: `validator`, Err: c.scrub(e)})
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// applyDefaults sets the namespace defaults missing from storage. Caller
+// must hold c.mu.
+func (c *Config) applyDefaults(storage map[string]string) {
+	for k, v := range c.defaults {
+		if _, ok := storage[k]; !ok {
+			storage[k] = v
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSSource reads properties from a NATS JetStream key-value bucket. KV keys
+// are property names (database.host). Updates to the bucket trigger a
+// Reload. TLS connections are not supported.
+type NATSSource struct {
+	// URL of the server, nats://host:4222 with user:password@ or token@
+	// for authentication
+	URL    string
+	Bucket string
+
+	DialTimeout time.Duration // 5s by default
+
+	mu       sync.Mutex
+	revision uint64
+}
+
+// Create source for bucket on the NATS server at url
+func NewNATSSource(url string, bucket string) *NATSSource {
+	return &NATSSource{URL: url, Bucket: bucket}
+}
+
+// Load reads the latest value of every key in the bucket
+func (s *NATSSource) Load(ctx context.Context) (map[string]string, error) {
+	conn, e := s.dial(ctx)
+	if e != nil {
+		return nil, e
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if e := s.consume(conn, `last_per_subject`); e != nil {
+		return nil, e
+	}
+
+	props := make(map[string]string)
+	var revision uint64
+	for {
+		msg, e := conn.next()
+		if e != nil {
+			return nil, s.error(e)
+		}
+
+		if msg.sid == natsReplySID {
+			pending, e := parseConsumerCreate(msg.data)
+			if e != nil {
+				return nil, s.error(e)
+			}
+			if pending == 0 {
+				break // empty bucket
+			}
+			continue
+		}
+
+		seq, pending := natsAckInfo(msg.reply)
+		if seq > revision {
+			revision = seq
+		}
+		name := normalizeKey(strings.TrimPrefix(msg.subject, `$KV.`+s.Bucket+`.`))
+		switch msg.header[`KV-Operation`] {
+		case `DEL`, `PURGE`:
+			delete(props, name)
+		default:
+			props[name] = string(msg.data)
+		}
+		if pending == 0 {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	s.revision = revision
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Watch calls notify for every update of the bucket
+func (s *NATSSource) Watch(ctx context.Context, notify func()) error {
+	conn, e := s.dial(ctx)
+	if e != nil {
+		return e
+	}
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	if e := s.consume(conn, `new`); e != nil {
+		return e
+	}
+	for {
+		msg, e := conn.next()
+		if e != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return s.error(e)
+		}
+
+		if msg.sid == natsReplySID {
+			if _, e := parseConsumerCreate(msg.data); e != nil {
+				return s.error(e)
+			}
+			continue
+		}
+		notify()
+	}
+}
+
+// Version returns the stream sequence of the latest update loaded
+func (s *NATSSource) Version() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.revision == 0 {
+		return ``
+	}
+	return strconv.FormatUint(s.revision, 10)
+}
+
+func (s *NATSSource) error(e error) error {
+	return fmt.Errorf(`nats kv %s: %v`, s.Bucket, e)
+}
+
+const (
+	natsDeliverSID = `1`
+	natsReplySID   = `2`
+)
+
+// consume creates an ephemeral push consumer of the bucket delivering to a
+// new inbox. The reply of the request arrives with sid natsReplySID, the
+// updates with sid natsDeliverSID.
+func (s *NATSSource) consume(conn *natsConn, policy string) error {
+	stream := `KV_` + s.Bucket
+	deliver, reply := natsInbox(), natsInbox()
+
+	req, e := json.Marshal(map[string]interface{}{
+		`stream_name`: stream,
+		`config`: map[string]interface{}{
+			`deliver_subject`: deliver,
+			`deliver_policy`:  policy,
+			`ack_policy`:      `none`,
+			`replay_policy`:   `instant`,
+			`filter_subject`:  `$KV.` + s.Bucket + `.>`,
+			`mem_storage`:     true,
+		},
+	})
+	if e != nil {
+		return e
+	}
+
+	if e := conn.send(`SUB `+deliver+` `+natsDeliverSID, nil); e != nil {
+		return e
+	}
+	if e := conn.send(`SUB `+reply+` `+natsReplySID, nil); e != nil {
+		return e
+	}
+	subject := `$JS.API.CONSUMER.CREATE.` + stream
+	if e := conn.send(`PUB `+subject+` `+reply+` `+strconv.Itoa(len(req)), req); e != nil {
+		return e
+	}
+	return nil
+}
+
+// parseConsumerCreate returns the number of messages pending for a new
+// consumer
+func parseConsumerCreate(data []byte) (uint64, error) {
+	var resp struct {
+		Error *struct {
+			Code        int    `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+		NumPending uint64 `json:"num_pending"`
+	}
+	if e := json.Unmarshal(data, &resp); e != nil {
+		return 0, e
+	}
+	if resp.Error != nil {
+		return 0, fmt.Errorf(`%d %s`, resp.Error.Code, resp.Error.Description)
+	}
+	return resp.NumPending, nil
+}
+
+// natsAckInfo returns the stream sequence and the number of pending messages
+// from the reply subject of a JetStream message, in the v1 or v2 format
+func natsAckInfo(reply string) (seq uint64, pending uint64) {
+	tokens := strings.Split(reply, `.`)
+	switch {
+	case len(tokens) == 9:
+		seq, _ = strconv.ParseUint(tokens[5], 10, 64)
+		pending, _ = strconv.ParseUint(tokens[8], 10, 64)
+	case len(tokens) >= 11:
+		seq, _ = strconv.ParseUint(tokens[7], 10, 64)
+		pending, _ = strconv.ParseUint(tokens[10], 10, 64)
+	}
+	return seq, pending
+}
+
+func natsInbox() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return `_INBOX.` + hex.EncodeToString(b)
+}
+
+func (s *NATSSource) dial(ctx context.Context) (*natsConn, error) {
+	u, e := url.Parse(s.URL)
+	if e != nil {
+		return nil, e
+	}
+	addr := u.Host
+	if u.Port() == `` {
+		addr = net.JoinHostPort(u.Hostname(), `4222`)
+	}
+
+	timeout := s.DialTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	d := net.Dialer{Timeout: timeout}
+	nc, e := d.DialContext(ctx, `tcp`, addr)
+	if e != nil {
+		return nil, e
+	}
+	conn := &natsConn{Conn: nc, r: bufio.NewReader(nc)}
+
+	opts := map[string]interface{}{
+		`verbose`:       false,
+		`pedantic`:      false,
+		`headers`:       true,
+		`no_responders`: true,
+		`name`:          `go-config`,
+		`lang`:          `go`,
+		`version`:       `1`,
+		`protocol`:      1,
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			opts[`user`], opts[`pass`] = u.User.Username(), pass
+		} else {
+			opts[`auth_token`] = u.User.Username()
+		}
+	}
+	connect, e := json.Marshal(opts)
+	if e != nil {
+		nc.Close()
+		return nil, e
+	}
+
+	nc.SetDeadline(time.Now().Add(timeout))
+	if e := conn.send(`CONNECT `+string(connect), nil); e == nil {
+		e = conn.send(`PING`, nil)
+	}
+	if e == nil {
+		e = conn.pong()
+	}
+	if e != nil {
+		nc.Close()
+		return nil, s.error(e)
+	}
+	nc.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// natsConn speaks the NATS client protocol
+type natsConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+type natsMsg struct {
+	subject string
+	sid     string
+	reply   string
+	header  map[string]string
+	data    []byte
+}
+
+func (c *natsConn) send(line string, payload []byte) error {
+	buf := append([]byte(line), "\r\n"...)
+	if payload != nil {
+		buf = append(append(buf, payload...), "\r\n"...)
+	}
+	_, e := c.Conn.Write(buf)
+	return e
+}
+
+// pong waits for the PONG answering the PING after CONNECT
+func (c *natsConn) pong() error {
+	for {
+		line, e := c.line()
+		if e != nil {
+			return e
+		}
+		switch {
+		case line == `PONG`:
+			return nil
+		case strings.HasPrefix(line, `-ERR`):
+			return errors.New(strings.Trim(strings.TrimSpace(line[4:]), `'`))
+		}
+	}
+}
+
+// next returns the next message, answering the pings of the server
+func (c *natsConn) next() (*natsMsg, error) {
+	for {
+		line, e := c.line()
+		if e != nil {
+			return nil, e
+		}
+
+		op, args, _ := strings.Cut(line, ` `)
+		switch strings.ToUpper(op) {
+		case `PING`:
+			if e := c.send(`PONG`, nil); e != nil {
+				return nil, e
+			}
+		case `-ERR`:
+			return nil, errors.New(strings.Trim(strings.TrimSpace(args), `'`))
+		case `MSG`, `HMSG`:
+			return c.msg(strings.ToUpper(op) == `HMSG`, strings.Fields(args))
+		}
+	}
+}
+
+// msg reads the payload of a MSG or HMSG with the arguments args
+func (c *natsConn) msg(headers bool, args []string) (*natsMsg, error) {
+	n := 3
+	if headers {
+		n = 4
+	}
+	if len(args) != n && len(args) != n+1 {
+		return nil, fmt.Errorf(`invalid message %q`, strings.Join(args, ` `))
+	}
+
+	msg := &natsMsg{subject: args[0], sid: args[1]}
+	if len(args) == n+1 {
+		msg.reply = args[2]
+	}
+	size, e := strconv.Atoi(args[len(args)-1])
+	if e != nil {
+		return nil, e
+	}
+	hsize := 0
+	if headers {
+		if hsize, e = strconv.Atoi(args[len(args)-2]); e != nil || hsize > size {
+			return nil, fmt.Errorf(`invalid message %q`, strings.Join(args, ` `))
+		}
+	}
+
+	buf := make([]byte, size+2)
+	if _, e := io.ReadFull(c.r, buf); e != nil {
+		return nil, e
+	}
+	msg.data = buf[hsize:size]
+
+	msg.header = make(map[string]string)
+	lines := strings.Split(string(buf[:hsize]), "\r\n")
+	for i, line := range lines {
+		if i == 0 {
+			// NATS/1.0 503 means JetStream is not enabled
+			if status := strings.Fields(line); len(status) > 1 && status[1] == `503` {
+				return nil, errors.New(`no responders, JetStream not enabled`)
+			}
+			continue
+		}
+		if k, v, ok := strings.Cut(line, `:`); ok {
+			msg.header[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return msg, nil
+}
+
+func (c *natsConn) line() (string, error) {
+	line, e := c.r.ReadString('\n')
+	if e != nil {
+		return ``, e
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
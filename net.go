@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Read property holding an absolute URL such as "https://api.example.com/v1".
+// Returns an error if property is not exists or has no scheme or host.
+func (c *Config) GetURL(name string) (*url.URL, error) {
+	var u *url.URL
+	e := c.parseKind(name, KindString, func(s string) (e error) {
+		if u, e = url.Parse(strings.TrimSpace(s)); e != nil {
+			return e
+		}
+		if u.Scheme == `` || u.Host == `` {
+			return fmt.Errorf(`%q is not an absolute URL`, s)
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return u, nil
+}
+
+// Read property holding an IPv4 or IPv6 address. Returns an error if
+// property is not exists or invalid.
+func (c *Config) GetIP(name string) (net.IP, error) {
+	var ip net.IP
+	e := c.parseKind(name, KindString, func(s string) error {
+		if ip = net.ParseIP(strings.TrimSpace(s)); ip == nil {
+			return fmt.Errorf(`%q is not an IP address`, s)
+		}
+		return nil
+	})
+	return ip, e
+}
+
+// Read property holding a network in CIDR notation such as "10.0.0.0/8".
+// Returns an error if property is not exists or invalid.
+func (c *Config) GetCIDR(name string) (*net.IPNet, error) {
+	var n *net.IPNet
+	e := c.parseKind(name, KindString, func(s string) (e error) {
+		_, n, e = net.ParseCIDR(strings.TrimSpace(s))
+		return e
+	})
+	return n, e
+}
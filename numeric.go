@@ -0,0 +1,204 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrKeyNotFound is returned by the E getters when the property is not set
+var ErrKeyNotFound = errors.New(`config: property not found`)
+
+// Read int64 property. If property is not exists or invalid will return 0
+func (c *Config) GetInt64(name string) int64 {
+	return c.GetInt64Or(name, 0)
+}
+
+// Read int64 property or return defValue if property is not exists or invalid
+func (c *Config) GetInt64Or(name string, defValue int64) int64 {
+	v, e := c.GetInt64E(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read int64 property, returns an error if property is not exists or invalid
+func (c *Config) GetInt64E(name string) (int64, error) {
+	var v int64
+	e := c.parseKind(name, KindInt, func(s string) (e error) {
+		v, e = strconv.ParseInt(s, 10, 64)
+		return e
+	})
+	return v, e
+}
+
+// Read int32 property. If property is not exists or invalid will return 0
+func (c *Config) GetInt32(name string) int32 {
+	return c.GetInt32Or(name, 0)
+}
+
+// Read int32 property or return defValue if property is not exists or invalid
+func (c *Config) GetInt32Or(name string, defValue int32) int32 {
+	v, e := c.GetInt32E(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read int32 property, returns an error if property is not exists, invalid
+// or out of the int32 range
+func (c *Config) GetInt32E(name string) (int32, error) {
+	var v int64
+	e := c.parseKind(name, KindInt, func(s string) (e error) {
+		v, e = strconv.ParseInt(s, 10, 32)
+		return e
+	})
+	return int32(v), e
+}
+
+// Read uint property. If property is not exists, invalid or out of the uint
+// range will return 0
+func (c *Config) GetUint(name string) uint {
+	return c.GetUintOr(name, 0)
+}
+
+// Read uint property or return defValue if property is not exists, invalid
+// or out of the uint range
+func (c *Config) GetUintOr(name string, defValue uint) uint {
+	v, e := c.GetUintE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read uint property, returns an error if property is not exists, invalid
+// or out of the uint range of the platform
+func (c *Config) GetUintE(name string) (uint, error) {
+	var v uint64
+	e := c.parseKind(name, KindInt, func(s string) (e error) {
+		v, e = strconv.ParseUint(s, 10, strconv.IntSize)
+		return e
+	})
+	return uint(v), e
+}
+
+// Read uint64 property. If property is not exists or invalid will return 0
+func (c *Config) GetUint64(name string) uint64 {
+	return c.GetUint64Or(name, 0)
+}
+
+// Read uint64 property or return defValue if property is not exists or invalid
+func (c *Config) GetUint64Or(name string, defValue uint64) uint64 {
+	v, e := c.GetUint64E(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read uint64 property, returns an error if property is not exists or invalid
+func (c *Config) GetUint64E(name string) (uint64, error) {
+	var v uint64
+	e := c.parseKind(name, KindInt, func(s string) (e error) {
+		v, e = strconv.ParseUint(s, 10, 64)
+		return e
+	})
+	return v, e
+}
+
+// Read float64 property. If property is not exists or invalid will return 0
+func (c *Config) GetFloat64(name string) float64 {
+	return c.GetFloat64Or(name, 0)
+}
+
+// Read float64 property or return defValue if property is not exists or invalid
+func (c *Config) GetFloat64Or(name string, defValue float64) float64 {
+	v, e := c.GetFloat64E(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read float64 property, returns an error if property is not exists or
+// invalid. Separators set with SetNumberFormat are accepted.
+func (c *Config) GetFloat64E(name string) (float64, error) {
+	return c.parseFloat(name, 64)
+}
+
+// Read float32 property. If property is not exists or invalid will return 0
+func (c *Config) GetFloat32(name string) float32 {
+	return c.GetFloat32Or(name, 0)
+}
+
+// Read float32 property or return defValue if property is not exists or invalid
+func (c *Config) GetFloat32Or(name string, defValue float32) float32 {
+	v, e := c.GetFloat32E(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read float32 property, returns an error if property is not exists or
+// invalid. Separators set with SetNumberFormat are accepted.
+func (c *Config) GetFloat32E(name string) (float32, error) {
+	v, e := c.parseFloat(name, 32)
+	return float32(v), e
+}
+
+func (c *Config) parseFloat(name string, bitSize int) (float64, error) {
+	c.mu.RLock()
+	numbers := c.numbers
+	c.mu.RUnlock()
+
+	var v float64
+	e := c.parseKind(name, KindFloat, func(s string) (e error) {
+		v, e = numbers.parseFloat(s, bitSize)
+		return e
+	})
+	return v, e
+}
+
+// Report values the getters without error result can not parse, instead
+// of silently returning the default. Missing properties still return the
+// default quietly; use the E getters, e.g. GetIntE, to handle both.
+func (c *Config) SetStrictMode(enable bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.strict = enable
+}
+
+// reportInvalid logs e in strict mode unless the property is only missing
+func (c *Config) reportInvalid(e error) {
+	c.mu.RLock()
+	strict := c.strict
+	c.mu.RUnlock()
+
+	if strict && !errors.Is(e, ErrKeyNotFound) {
+		fmt.Println(`Invalid config property:`, e)
+	}
+}
+
+// parseProperty calls parse with the value of property name and wraps its
+// error with the property name
+func (c *Config) parseProperty(name string, parse func(string) error) error {
+	val, ok := c.lookup(name)
+	if !ok {
+		return c.notFound(name)
+	}
+	if e := parse(val); e != nil {
+		return c.scrub(fmt.Errorf(`config: %s: %w`, name, e))
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Source reads a config object from Amazon S3 or an S3 compatible store.
+// The object is verified against its SHA-256 checksum or MD5 ETag and
+// polled for a new version every Interval.
+type S3Source struct {
+	Bucket      string
+	Key         string
+	Region      string // AWS_REGION by default
+	Endpoint    string // e.g. http://minio:9000 (path style), AWS by default
+	Credentials AWSCredentials
+	Client      *http.Client
+
+	// Format of the object, by default taken from the key extension, the
+	// Content-Type, then the content, like Open does
+	Format string
+	// Interval between checks for a new version, zero disables them
+	Interval time.Duration
+
+	mu   sync.Mutex
+	etag string
+}
+
+// Create source for the object key in bucket, checked for a new version
+// every minute
+func NewS3Source(bucket string, key string) *S3Source {
+	return &S3Source{Bucket: bucket, Key: strings.TrimPrefix(key, "/"), Interval: time.Minute}
+}
+
+// Load downloads and parses the object
+func (s *S3Source) Load(ctx context.Context) (map[string]string, error) {
+	r, e := s.request(ctx, http.MethodGet)
+	if e != nil {
+		return nil, e
+	}
+	defer r.Body.Close()
+
+	data, e := io.ReadAll(r.Body)
+	if e != nil {
+		return nil, e
+	}
+	if e := verifyS3(data, r.Header); e != nil {
+		return nil, fmt.Errorf(`s3://%s/%s: %v`, s.Bucket, s.Key, e)
+	}
+
+	props, e := parseObject(`s3://`+s.Bucket+`/`+s.Key, data, s.Format, r.Header.Get(`Content-Type`))
+	if e != nil {
+		return nil, e
+	}
+
+	s.mu.Lock()
+	s.etag = r.Header.Get(`ETag`)
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Watch calls notify when the ETag of the object changed
+func (s *S3Source) Watch(ctx context.Context, notify func()) error {
+	return pollWatch(ctx, s.Interval, func() {
+		r, e := s.request(ctx, http.MethodHead)
+		if e != nil {
+			notify() // the reload records the error
+			return
+		}
+		r.Body.Close()
+
+		s.mu.Lock()
+		changed := r.Header.Get(`ETag`) != s.etag
+		s.mu.Unlock()
+		if changed {
+			notify()
+		}
+	})
+}
+
+func (s *S3Source) pollInterval() time.Duration {
+	return s.Interval
+}
+
+// request sends a signed GET or HEAD for the object
+func (s *S3Source) request(ctx context.Context, method string) (*http.Response, error) {
+	client, e := newAWSClient(`s3`, s.Region, s.Endpoint, s.Credentials, s.Client)
+	if e != nil {
+		return nil, e
+	}
+
+	u := client.endpoint + "/" + s.Bucket + "/" + escapeObjectKey(s.Key)
+	if s.Endpoint == `` {
+		u = `https://` + s.Bucket + `.s3.` + client.region + `.amazonaws.com/` + escapeObjectKey(s.Key)
+	}
+	req, e := http.NewRequestWithContext(ctx, method, u, nil)
+	if e != nil {
+		return nil, e
+	}
+	req.Header.Set(`X-Amz-Checksum-Mode`, `ENABLED`)
+
+	r, e := client.do(req, nil)
+	if e != nil {
+		return nil, e
+	}
+	if r.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(r.Body)
+		r.Body.Close()
+		return nil, fmt.Errorf(`s3://%s/%s: %s: %s`, s.Bucket, s.Key, r.Status, bytes.TrimSpace(data))
+	}
+	return r, nil
+}
+
+// verifyS3 checks data against the SHA-256 checksum, else the ETag when it
+// is the MD5 of a single part upload
+func verifyS3(data []byte, h http.Header) error {
+	if sum := h.Get(`X-Amz-Checksum-Sha256`); sum != `` && !strings.Contains(sum, `-`) {
+		actual := sha256.Sum256(data)
+		if base64.StdEncoding.EncodeToString(actual[:]) != sum {
+			return errChecksum
+		}
+		return nil
+	}
+
+	etag := strings.Trim(h.Get(`ETag`), `"`)
+	if len(etag) == 32 {
+		actual := md5.Sum(data)
+		if hex.EncodeToString(actual[:]) != etag {
+			return errChecksum
+		}
+	}
+	return nil
+}
+
+// GCSSource reads a config object from Google Cloud Storage. The object is
+// verified against its MD5 or CRC32C hash and polled for a new generation
+// every Interval.
+type GCSSource struct {
+	Bucket string
+	Object string
+	// Token is the OAuth access token. When blank GOOGLE_OAUTH_ACCESS_TOKEN
+	// is used, then the token of the GCE/GKE metadata server, then none for
+	// public objects.
+	Token    string
+	Endpoint string // https://storage.googleapis.com by default
+	Client   *http.Client
+
+	// Format of the object, by default taken from the object extension, the
+	// Content-Type, then the content, like Open does
+	Format string
+	// Interval between checks for a new generation, zero disables them
+	Interval time.Duration
+
+	mu          sync.Mutex
+	generation  string
+	token       string
+	tokenExpiry time.Time
+}
+
+// Create source for object in bucket, checked for a new generation every
+// minute
+func NewGCSSource(bucket string, object string) *GCSSource {
+	return &GCSSource{Bucket: bucket, Object: strings.TrimPrefix(object, "/"), Interval: time.Minute}
+}
+
+// Load downloads and parses the object
+func (s *GCSSource) Load(ctx context.Context) (map[string]string, error) {
+	r, e := s.get(ctx, `media`)
+	if e != nil {
+		return nil, e
+	}
+	defer r.Body.Close()
+
+	data, e := io.ReadAll(r.Body)
+	if e != nil {
+		return nil, e
+	}
+	if e := verifyGCS(data, r.Header.Values(`X-Goog-Hash`)); e != nil {
+		return nil, fmt.Errorf(`gs://%s/%s: %v`, s.Bucket, s.Object, e)
+	}
+
+	props, e := parseObject(`gs://`+s.Bucket+`/`+s.Object, data, s.Format, r.Header.Get(`Content-Type`))
+	if e != nil {
+		return nil, e
+	}
+
+	s.mu.Lock()
+	s.generation = r.Header.Get(`X-Goog-Generation`)
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Watch calls notify when the generation of the object changed
+func (s *GCSSource) Watch(ctx context.Context, notify func()) error {
+	return pollWatch(ctx, s.Interval, func() {
+		r, e := s.get(ctx, `json`)
+		if e != nil {
+			notify() // the reload records the error
+			return
+		}
+		defer r.Body.Close()
+
+		var meta struct {
+			Generation string `json:"generation"`
+		}
+		if e := json.NewDecoder(r.Body).Decode(&meta); e != nil {
+			notify()
+			return
+		}
+
+		s.mu.Lock()
+		changed := meta.Generation != s.generation
+		s.mu.Unlock()
+		if changed {
+			notify()
+		}
+	})
+}
+
+func (s *GCSSource) pollInterval() time.Duration {
+	return s.Interval
+}
+
+// get requests the object content (alt media) or its metadata (alt json)
+func (s *GCSSource) get(ctx context.Context, alt string) (*http.Response, error) {
+	endpoint := strings.TrimRight(s.Endpoint, "/")
+	if endpoint == `` {
+		endpoint = `https://storage.googleapis.com`
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	u := endpoint + `/storage/v1/b/` + url.PathEscape(s.Bucket) + `/o/` + url.PathEscape(s.Object) + `?alt=` + alt
+	req, e := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if e != nil {
+		return nil, e
+	}
+	if token := s.accessToken(ctx, client); token != `` {
+		req.Header.Set(`Authorization`, `Bearer `+token)
+	}
+
+	r, e := client.Do(req)
+	if e != nil {
+		return nil, e
+	}
+	if r.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(r.Body)
+		r.Body.Close()
+		return nil, fmt.Errorf(`gs://%s/%s: %s: %s`, s.Bucket, s.Object, r.Status, bytes.TrimSpace(data))
+	}
+	return r, nil
+}
+
+const gceTokenURL = `http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token`
+
+// accessToken returns the configured token or one of the metadata server,
+// blank when there is none
+func (s *GCSSource) accessToken(ctx context.Context, client *http.Client) string {
+	if s.Token != `` {
+		return s.Token
+	}
+	if token := os.Getenv(`GOOGLE_OAUTH_ACCESS_TOKEN`); token != `` {
+		return token
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != `` && time.Until(s.tokenExpiry) > time.Minute {
+		return s.token
+	}
+
+	req, e := http.NewRequestWithContext(ctx, http.MethodGet, gceTokenURL, nil)
+	if e != nil {
+		return ``
+	}
+	req.Header.Set(`Metadata-Flavor`, `Google`)
+	r, e := client.Do(req)
+	if e != nil {
+		return ``
+	}
+	defer r.Body.Close()
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if r.StatusCode != http.StatusOK || json.NewDecoder(r.Body).Decode(&resp) != nil {
+		return ``
+	}
+	s.token = resp.AccessToken
+	s.tokenExpiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	return s.token
+}
+
+// verifyGCS checks data against the md5 or crc32c of x-goog-hash headers
+func verifyGCS(data []byte, hashes []string) error {
+	for _, h := range hashes {
+		for _, part := range strings.Split(h, `,`) {
+			name, sum, _ := strings.Cut(strings.TrimSpace(part), `=`)
+			var actual []byte
+			switch name {
+			case `md5`:
+				s := md5.Sum(data)
+				actual = s[:]
+			case `crc32c`:
+				c := crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+				actual = []byte{byte(c >> 24), byte(c >> 16), byte(c >> 8), byte(c)}
+			default:
+				continue
+			}
+			if base64.StdEncoding.EncodeToString(actual) != sum {
+				return errChecksum
+			}
+		}
+	}
+	return nil
+}
+
+var errChecksum = errors.New(`checksum mismatch`)
+
+// escapeObjectKey escapes every part of an object key, keeping the slashes
+func escapeObjectKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// parseObject reads a downloaded config document called name. Without
+// format it is chosen like Open does by extension, then like OpenRemote by
+// Content-Type, then by content.
+func parseObject(name string, data []byte, format string, contentType string) (map[string]string, error) {
+	format = strings.TrimPrefix(strings.ToLower(format), ".")
+	if format == `` {
+		ext := strings.ToLower(path.Ext(name))
+		if _, ok := lookupParser(ext); ok {
+			format = strings.TrimPrefix(ext, ".")
+		} else {
+			format = remoteFormat(name, contentType)
+		}
+	}
+	if format == `` {
+		format = sniffFormat(data)
+	}
+
+	tmp := &Config{storage: make(map[string]string)}
+	f := &File{filename: name, data: data}
+	if e := f.readFormat(tmp, format); e != nil {
+		return nil, e
+	}
+	return tmp.storage, nil
+}
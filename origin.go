@@ -0,0 +1,52 @@
+package config
+
+// Return where the current value of property comes from: "env", "override"
+// (Set), "map" (LoadMap or LoadCSV), the file or source name that set it
+// last, or "default" (Namespace.SetDefault). Blank when property is not set.
+func (c *Config) Origin(name string) string {
+	if c.parent != nil {
+		return c.parent.Origin(c.subPrefix + normalizeKey(name))
+	}
+
+	name = normalizeKey(name)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.origin(name)
+}
+
+// origin is Origin for a normalized name. Caller must hold c.mu.
+func (c *Config) origin(name string) string {
+	if _, ok := c.lookupEnv(name); ok {
+		return `env`
+	}
+	if _, ok := c.storage[name]; !ok {
+		return ``
+	}
+	if _, ok := c.overrides[name]; ok {
+		return `override`
+	}
+	for i := len(c.layers) - 1; i >= 0; i-- {
+		if _, ok := c.layers[i].storage[name]; ok {
+			return `map`
+		}
+	}
+	if src, ok := c.origins[name]; ok {
+		return src
+	}
+	if _, ok := c.defaults[name]; ok {
+		return `default`
+	}
+	return ``
+}
+
+// setOrigin records source as the origin of every property of layer
+func (c *Config) setOrigin(layer map[string]string, source string) {
+	if c.origins == nil {
+		c.origins = make(map[string]string)
+	}
+	for k := range layer {
+		c.origins[k] = source
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Set property value at runtime. The value overrides the config files and is
+// applied again after every Reload. When an overrides file is configured the
+// value is also persisted there, and with a remote sink it is pushed upstream
+// first.
+func (c *Config) Set(name string, value string) error {
+	if c.parent != nil {
+		return c.parent.Set(c.subPrefix+normalizeKey(name), value)
+	}
+	name = normalizeKey(name)
+
+	if e := c.pushRemote(name, value); e != nil {
+		return e
+	}
+
+	c.mu.Lock()
+	if c.overrides == nil {
+		c.overrides = make(map[string]string)
+	}
+	if c.storage == nil {
+		c.storage = make(map[string]string)
+	}
+	old, existed := c.storage[name]
+	c.overrides[name] = value
+	delete(c.deleted, name)
+	c.storage[name] = value
+	path := c.overridesPath
+	fsys := c.filesystem()
+	overrides := copyStorage(c.overrides)
+	c.rewriteOverrides(overrides)
+	c.mu.Unlock()
+
+	if !existed {
+		c.publish([]Change{{Key: name, Op: `add`, New: value}})
+	} else if old != value {
+		c.publish([]Change{{Key: name, Op: `update`, Old: old, New: value}})
+	}
+
+	if path == `` {
+		return nil
+	}
+	return writeOverrides(fsys, path, overrides)
+}
+
+// Keep runtime overrides made with Set in the JSON file at path, separate
+// from the config files. Overrides already stored in the file are applied
+// on top of the config. Call before Open.
+func (c *Config) SetOverridesFile(path string) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	c.mu.RLock()
+	fsys := c.filesystem()
+	c.mu.RUnlock()
+
+	overrides, e := readOverrides(fsys, path)
+	if e != nil {
+		return e
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.overridesPath = path
+	if c.overrides == nil {
+		c.overrides = make(map[string]string)
+	}
+	for k, v := range overrides {
+		c.overrides[k] = v
+		if c.storage != nil {
+			c.storage[k] = v
+		}
+	}
+	return nil
+}
+
+// Return a copy of the runtime overrides
+func (c *Config) GetOverrides() map[string]string {
+	if c.parent != nil {
+		out := make(map[string]string)
+		for k, v := range c.parent.GetOverrides() {
+			if strings.HasPrefix(k, c.subPrefix) {
+				out[k[len(c.subPrefix):]] = v
+			}
+		}
+		return out
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return copyStorage(c.overrides)
+}
+
+// applyOverrides copies the runtime overrides into storage and removes the
+// properties deleted with Update
+func (c *Config) applyOverrides(storage map[string]string) {
+	for k, v := range c.overrides {
+		storage[k] = v
+	}
+	for k := range c.deleted {
+		delete(storage, k)
+	}
+}
+
+func readOverrides(fsys FS, path string) (map[string]string, error) {
+	data, e := readFile(fsys, path)
+	if errors.Is(e, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if e != nil {
+		return nil, e
+	}
+
+	var v map[string]interface{}
+	if e := json.Unmarshal(data, &v); e != nil {
+		return nil, fmt.Errorf(`%s: %v`, path, e)
+	}
+
+	// top level keys are full property names, nested objects are accepted too
+	overrides := make(map[string]string)
+	for k, val := range v {
+		flatten(k, val, overrides)
+	}
+	return overrides, nil
+}
+
+func writeOverrides(fsys FS, path string, overrides map[string]string) error {
+	data, e := json.MarshalIndent(overrides, "", "  ")
+	if e != nil {
+		return e
+	}
+
+	return writeFile(fsys, path, append(data, '\n'))
+}
+
+func copyStorage(storage map[string]string) map[string]string {
+	out := make(map[string]string, len(storage))
+	for k, v := range storage {
+		out[k] = v
+	}
+	return out
+}
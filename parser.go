@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ParserFunc reads the config file at path and stores its properties in storage
+type ParserFunc func(path string, storage map[string]string) error
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[string]ParserFunc{}
+)
+
+// Register parser for files with extension ext (e.g. ".yaml"). Registered
+// parsers take precedence over the built-in formats. The extension (without
+// dot) can also be used as format name in SetFileFormat.
+func RegisterParser(ext string, fn ParserFunc) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	parsers[normalizeExt(ext)] = fn
+}
+
+func lookupParser(ext string) (ParserFunc, bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	fn, ok := parsers[normalizeExt(ext)]
+	return fn, ok
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext != `` && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// readWithParser runs a registered parser for the file
+func (f *File) readWithParser(c *Config, fn ParserFunc) error {
+	if f.data != nil {
+		return fmt.Errorf(`%s: registered parsers only read files`, f.filename)
+	}
+
+	fmt.Println(`Read config:`, f.filename)
+	c.file = append(c.file, f.filename)
+
+	return fn(f.filename, c.storage)
+}
@@ -0,0 +1,40 @@
+// Package plugin loads Go plugins that extend a config.Config, kept out of
+// the config package so programs that do not load plugins do not link the
+// plugin runtime.
+package plugin
+
+import (
+	"fmt"
+	goplugin "plugin"
+
+	config "github.com/budimanlai/go-config"
+)
+
+// Load Go plugins (built with go build -buildmode=plugin) that extend c,
+// e.g. from paths set by operators. Each plugin exports
+//
+//	func Register(c *config.Config) error
+//
+// which typically calls AddResolver or AddValidator. Plugins must be built
+// with the same Go version and config package as the program, and are only
+// supported where the plugin package is (Linux, FreeBSD and macOS with cgo).
+func Load(c *config.Config, paths ...string) error {
+	for _, path := range paths {
+		p, e := goplugin.Open(path)
+		if e != nil {
+			return fmt.Errorf(`config: plugin %s: %v`, path, e)
+		}
+		sym, e := p.Lookup(`Register`)
+		if e != nil {
+			return fmt.Errorf(`config: plugin %s: %v`, path, e)
+		}
+		register, ok := sym.(func(*config.Config) error)
+		if !ok {
+			return fmt.Errorf(`config: plugin %s: Register is %T, not func(*config.Config) error`, path, sym)
+		}
+		if e := register(c); e != nil {
+			return fmt.Errorf(`config: plugin %s: %v`, path, e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Read Java .properties file. Keys are stored as written, so key.sub=value
+// is read with GetString("key.sub"). Supports `=`, `:` or whitespace
+// separators, `#`/`!` comments, trailing backslash line continuations and
+// \uXXXX escapes.
+func (f *File) ReadProperties(c *Config) error {
+	fi, e := f.open(c)
+	if e != nil {
+		return e
+	}
+	defer fi.Close()
+
+	fmt.Println(`Read config:`, f.filename)
+	c.file = append(c.file, f.filename)
+
+	scanner := bufio.NewScanner(fi)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if line == `` || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		for continuesLine(line) {
+			line = line[:len(line)-1]
+			if !scanner.Scan() {
+				break
+			}
+			lineNo++
+			line += strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+
+		key, val := splitProperty(line)
+		k, e := unescapeProperty(key)
+		if e != nil {
+			return fmt.Errorf(`%s:%d: %v`, f.filename, lineNo, e)
+		}
+		v, e := unescapeProperty(val)
+		if e != nil {
+			return fmt.Errorf(`%s:%d: %v`, f.filename, lineNo, e)
+		}
+		c.storage[k] = v
+	}
+	return scanner.Err()
+}
+
+// continuesLine reports whether line ends with an odd number of backslashes
+func continuesLine(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty splits line at the first unescaped `=`, `:` or whitespace
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != `` && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
+		}
+	}
+	return line, ``
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return ``, fmt.Errorf(`invalid unicode escape`)
+			}
+			r, e := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if e != nil {
+				return ``, fmt.Errorf(`invalid unicode escape \u%s`, s[i+1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Read config from r, e.g. stdin or a network stream, merged after the files
+// already opened. format is one of the formats accepted by SetFileFormat,
+// blank to detect it from the content. The content is kept in memory and
+// read again on every Reload; reader sources are not watched.
+func (c *Config) OpenReader(r io.Reader, format string) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	if r == nil {
+		return errors.New(`Reader is nil`)
+	}
+	if e := c.checkNotClosed(); e != nil {
+		return e
+	}
+
+	data, e := io.ReadAll(r)
+	if e != nil {
+		return e
+	}
+	if data == nil {
+		data = []byte{}
+	}
+
+	format = strings.TrimPrefix(strings.ToLower(format), ".")
+	if format == `` {
+		format = sniffFormat(data)
+	}
+
+	c.mu.Lock()
+	name := fmt.Sprintf(`reader#%d`, len(c.readers)+1)
+	c.setReader(name, data)
+	c.setFormat(name, format)
+	sources := c.sources
+	c.sources = append(append([]string(nil), sources...), name)
+	c.mu.Unlock()
+
+	if _, e := c.load(); e != nil {
+		// the content will not change, so drop it instead of failing every Reload
+		c.mu.Lock()
+		c.sources = sources
+		c.setReader(name, nil)
+		c.setFormat(name, ``)
+		c.mu.Unlock()
+		return e
+	}
+	return nil
+}
+
+// Merge config data in the given format over the current properties, e.g.
+// LoadBytes(body, "json") for a config fetched by the caller. Like
+// OpenReader the data is kept and applied again on every Reload.
+func (c *Config) LoadBytes(data []byte, format string) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	return c.OpenReader(bytes.NewReader(data), format)
+}
+
+// Merge config text in the given format over the current properties
+func (c *Config) LoadString(data string, format string) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	return c.OpenReader(strings.NewReader(data), format)
+}
+
+// setReader stores the content of an in-memory source, nil removes it. The
+// map is replaced rather than changed, as loads read it without the lock.
+// Caller must hold c.mu.
+func (c *Config) setReader(name string, data []byte) {
+	readers := make(map[string]*File, len(c.readers)+1)
+	for k, v := range c.readers {
+		readers[k] = v
+	}
+	if data != nil {
+		readers[name] = &File{filename: name, data: data}
+	} else {
+		delete(readers, name)
+	}
+	c.readers = readers
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisSource reads properties from Redis: the fields of a hash, or the
+// string keys starting with KeyPrefix (myapp:database:host becomes
+// database.host for the prefix myapp:). A message published on Channel
+// triggers a Reload, so an admin tool can push changes to every service.
+type RedisSource struct {
+	Addr      string // host:port
+	Password  string
+	DB        int
+	Hash      string
+	KeyPrefix string // used when Hash is blank
+	Channel   string // blank disables the subscription
+
+	DialTimeout time.Duration // 5s by default, also bounds AUTH and SELECT
+}
+
+// Create source for the fields of hash on the Redis server at addr,
+// reloaded on messages published on channel
+func NewRedisSource(addr string, hash string, channel string) *RedisSource {
+	return &RedisSource{Addr: addr, Hash: hash, Channel: channel}
+}
+
+// Load reads the hash or the keys under KeyPrefix. It fails when the server
+// does not answer before the deadline of ctx, or within 30s without one.
+func (s *RedisSource) Load(ctx context.Context) (map[string]string, error) {
+	conn, e := s.dial(ctx)
+	if e != nil {
+		return nil, e
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(30 * time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	if s.Hash != `` {
+		reply, e := conn.do(`HGETALL`, s.Hash)
+		if e != nil {
+			return nil, e
+		}
+		items, _ := reply.([]interface{})
+		props := make(map[string]string, len(items)/2)
+		for i := 0; i+1 < len(items); i += 2 {
+			k, _ := items[i].(string)
+			v, _ := items[i+1].(string)
+			props[normalizeKey(k)] = v
+		}
+		return props, nil
+	}
+
+	var keys []string
+	cursor := `0`
+	for {
+		reply, e := conn.do(`SCAN`, cursor, `MATCH`, redisPattern(s.KeyPrefix)+`*`, `COUNT`, `500`)
+		if e != nil {
+			return nil, e
+		}
+		page, _ := reply.([]interface{})
+		if len(page) != 2 {
+			return nil, errors.New(`redis: invalid SCAN reply`)
+		}
+		cursor, _ = page[0].(string)
+		batch, _ := page[1].([]interface{})
+		for _, k := range batch {
+			if key, ok := k.(string); ok {
+				keys = append(keys, key)
+			}
+		}
+		if cursor == `0` {
+			break
+		}
+	}
+
+	props := make(map[string]string, len(keys))
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > 500 {
+			n = 500
+		}
+		reply, e := conn.do(append([]string{`MGET`}, keys[:n]...)...)
+		if e != nil {
+			return nil, e
+		}
+		values, _ := reply.([]interface{})
+		for i, v := range values {
+			val, ok := v.(string)
+			name := propertyName(strings.TrimPrefix(keys[i], s.KeyPrefix), `:`)
+			if ok && name != `` {
+				props[name] = val
+			}
+		}
+		keys = keys[n:]
+	}
+	return props, nil
+}
+
+// Watch subscribes to Channel and calls notify for every message
+func (s *RedisSource) Watch(ctx context.Context, notify func()) error {
+	if s.Channel == `` {
+		return nil
+	}
+
+	conn, e := s.dial(ctx)
+	if e != nil {
+		return e
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if e := conn.send(`SUBSCRIBE`, s.Channel); e != nil {
+		return e
+	}
+	for {
+		reply, e := conn.read()
+		if e != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return e
+		}
+		msg, _ := reply.([]interface{})
+		if len(msg) == 3 && msg[0] == `message` {
+			notify()
+		}
+	}
+}
+
+func (s *RedisSource) dial(ctx context.Context) (*redisConn, error) {
+	timeout := s.DialTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	d := net.Dialer{Timeout: timeout}
+	nc, e := d.DialContext(ctx, `tcp`, s.Addr)
+	if e != nil {
+		return nil, e
+	}
+
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	nc.SetDeadline(time.Now().Add(timeout))
+	if s.Password != `` {
+		if _, e := conn.do(`AUTH`, s.Password); e != nil {
+			conn.Close()
+			return nil, e
+		}
+	}
+	if s.DB != 0 {
+		if _, e := conn.do(`SELECT`, strconv.Itoa(s.DB)); e != nil {
+			conn.Close()
+			return nil, e
+		}
+	}
+	nc.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// redisPattern escapes the glob characters of a SCAN MATCH pattern
+func redisPattern(prefix string) string {
+	var b strings.Builder
+	for _, ch := range prefix {
+		if strings.ContainsRune(`*?[]\`, ch) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// redisConn speaks the RESP2 protocol. Replies are strings, int64, nil,
+// errors or []interface{} of them.
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	if e := c.send(args...); e != nil {
+		return nil, e
+	}
+	reply, e := c.read()
+	if e != nil {
+		return nil, e
+	}
+	if re, ok := reply.(redisError); ok {
+		return nil, re
+	}
+	return reply, nil
+}
+
+func (c *redisConn) send(args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, e := io.WriteString(c.Conn, b.String())
+	return e
+}
+
+type redisError string
+
+func (e redisError) Error() string {
+	return `redis: ` + string(e)
+}
+
+func (c *redisConn) read() (interface{}, error) {
+	line, e := c.r.ReadString('\n')
+	if e != nil {
+		return nil, e
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == `` {
+		return nil, errors.New(`redis: invalid reply`)
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return redisError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, e := strconv.Atoi(line[1:])
+		if e != nil || n < 0 {
+			return nil, e
+		}
+		buf := make([]byte, n+2)
+		if _, e := io.ReadFull(c.r, buf); e != nil {
+			return nil, e
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, e := strconv.Atoi(line[1:])
+		if e != nil || n < 0 {
+			return nil, e
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], e = c.read(); e != nil {
+				return nil, e
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf(`redis: invalid reply %q`, line)
+}
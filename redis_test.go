@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves RESP on a local listener. reply returns the raw reply to
+// a command, blank to send nothing.
+func fakeRedis(t *testing.T, reply func(cmd []string) string) string {
+	t.Helper()
+
+	ln, e := net.Listen(`tcp`, `127.0.0.1:0`)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var mu sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		ln.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, nc := range conns {
+			nc.Close()
+		}
+	})
+
+	go func() {
+		for {
+			nc, e := ln.Accept()
+			if e != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, nc)
+			mu.Unlock()
+
+			go func() {
+				defer nc.Close()
+				conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+				for {
+					req, e := conn.read()
+					if e != nil {
+						return
+					}
+					items, _ := req.([]interface{})
+					cmd := make([]string, len(items))
+					for i, item := range items {
+						cmd[i], _ = item.(string)
+					}
+					if out := reply(cmd); out != `` {
+						if _, e := nc.Write([]byte(out)); e != nil {
+							return
+						}
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRedisLoadHash(t *testing.T) {
+	var got []string
+	addr := fakeRedis(t, func(cmd []string) string {
+		got = append(got, cmd[0])
+		switch cmd[0] {
+		case `AUTH`:
+			if cmd[1] != `pw` {
+				return "-WRONGPASS invalid password\r\n"
+			}
+			return "+OK\r\n"
+		case `SELECT`:
+			return "+OK\r\n"
+		case `HGETALL`:
+			return "*4\r\n$13\r\ndatabase.host\r\n$2\r\ndb\r\n$4\r\nport\r\n:5432\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	})
+
+	s := &RedisSource{Addr: addr, Password: `pw`, DB: 2, Hash: `myapp`}
+	props, e := s.Load(context.Background())
+	if e != nil {
+		t.Fatal(e)
+	}
+	if props[`database.host`] != `db` || len(props) != 2 {
+		t.Errorf(`props = %v`, props)
+	}
+	if strings.Join(got, ` `) != `AUTH SELECT HGETALL` {
+		t.Errorf(`commands = %v`, got)
+	}
+
+	s.Password = `bad`
+	if _, e := s.Load(context.Background()); e == nil || !strings.Contains(e.Error(), `WRONGPASS`) {
+		t.Errorf(`Load with a bad password = %v`, e)
+	}
+}
+
+func TestRedisLoadKeyPrefix(t *testing.T) {
+	addr := fakeRedis(t, func(cmd []string) string {
+		switch {
+		case cmd[0] == `SCAN` && cmd[1] == `0`:
+			if cmd[3] != `my\*app:*` {
+				return "-ERR bad pattern " + cmd[3] + "\r\n"
+			}
+			return "*2\r\n$1\r\n7\r\n*1\r\n$14\r\nmy*app:db:host\r\n"
+		case cmd[0] == `SCAN`:
+			return "*2\r\n$1\r\n0\r\n*2\r\n$14\r\nmy*app:db:port\r\n$12\r\nmy*app:empty\r\n"
+		case cmd[0] == `MGET`:
+			return "*3\r\n$2\r\ndb\r\n$4\r\n5432\r\n$-1\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	})
+
+	props, e := (&RedisSource{Addr: addr, KeyPrefix: `my*app:`}).Load(context.Background())
+	if e != nil {
+		t.Fatal(e)
+	}
+	if props[`db.host`] != `db` || props[`db.port`] != `5432` || len(props) != 2 {
+		t.Errorf(`props = %v`, props)
+	}
+}
+
+func TestRedisLoadDeadline(t *testing.T) {
+	addr := fakeRedis(t, func(cmd []string) string {
+		return `` // a server that stopped answering
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, e := (&RedisSource{Addr: addr, Hash: `myapp`}).Load(ctx)
+	var ne net.Error
+	if !errors.As(e, &ne) || !ne.Timeout() {
+		t.Errorf(`Load = %v, want a timeout`, e)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf(`Load returned after %v`, d)
+	}
+
+	_, e = (&RedisSource{Addr: addr, Password: `pw`, Hash: `myapp`, DialTimeout: 100 * time.Millisecond}).Load(context.Background())
+	if !errors.As(e, &ne) || !ne.Timeout() {
+		t.Errorf(`Load with AUTH unanswered = %v, want a timeout`, e)
+	}
+}
+
+func TestRedisWatch(t *testing.T) {
+	subscribed := make(chan struct{}, 1)
+	addr := fakeRedis(t, func(cmd []string) string {
+		if cmd[0] != `SUBSCRIBE` {
+			return "-ERR unknown command\r\n"
+		}
+		subscribed <- struct{}{}
+		return "*3\r\n$9\r\nsubscribe\r\n$7\r\nreloads\r\n:1\r\n" +
+			"*3\r\n$7\r\nmessage\r\n$7\r\nreloads\r\n$2\r\ngo\r\n"
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notified := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewRedisSource(addr, `myapp`, `reloads`).Watch(ctx, func() { notified <- struct{}{} })
+	}()
+
+	<-subscribed
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`no notify for the published message`)
+	}
+
+	cancel()
+	if e := <-done; e != nil {
+		t.Errorf(`Watch after cancel = %v`, e)
+	}
+	waitNoRedisWatchers(t)
+}
+
+func TestRedisWatchError(t *testing.T) {
+	addr := fakeRedis(t, func(cmd []string) string {
+		return "*3\r\n$9\r\nsubscribe\r\n$7\r\nreloads\r\n:1\r\n$x\r\n" // invalid reply
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if e := NewRedisSource(addr, `myapp`, `reloads`).Watch(ctx, func() {}); e == nil {
+		t.Fatal(`Watch returned nil on an invalid reply`)
+	}
+
+	// the goroutine closing the connection on cancel must not outlive Watch
+	waitNoRedisWatchers(t)
+}
+
+// waitNoRedisWatchers fails t when a goroutine started by Watch is still
+// running after a second
+func waitNoRedisWatchers(t *testing.T) {
+	t.Helper()
+
+	buf := make([]byte, 1<<20)
+	for start := time.Now(); ; {
+		stacks := string(buf[:runtime.Stack(buf, true)])
+		if !strings.Contains(stacks, `(*RedisSource).Watch.func`) {
+			return
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("Watch goroutine still running:\n%s", stacks)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrConflict is returned by a RemoteSink when the remote value was changed
+// since the version the caller last saw
+var ErrConflict = errors.New(`remote value was changed concurrently`)
+
+// RemoteSink stores properties written with Set in a central store (etcd,
+// Consul, S3, ...).
+type RemoteSink interface {
+	// Put stores value under key. version is the remote version last seen
+	// for key, 0 when the key was never seen, in which case it is only
+	// created if it does not exist yet. Implementations supporting versions
+	// return ErrConflict when the remote version differs, and the new
+	// version on success.
+	Put(key string, value string, version int64) (int64, error)
+}
+
+// RemotePut is one write of a TxnSink transaction
+type RemotePut struct {
+	Key     string
+	Value   string
+	Version int64 // as passed to RemoteSink.Put
+}
+
+// TxnSink is a RemoteSink that can write several keys in one transaction,
+// used by Update
+type TxnSink interface {
+	RemoteSink
+	// PutAll stores every value or none. It returns ErrConflict when the
+	// version of any key differs, and the new versions in the order of puts
+	// on success.
+	PutAll(puts []RemotePut) ([]int64, error)
+}
+
+// Push every value written with Set to sink before applying it locally. A
+// failed push (including ErrConflict) leaves the local value unchanged. The
+// versions compared are the ones loaded by a RevisionSource reading the
+// same keys, such as EtcdSource for EtcdSink, and the ones returned by
+// earlier writes; keys never seen are only created, never overwritten.
+func (c *Config) SetRemoteSink(sink RemoteSink) {
+	if c.parent != nil {
+		c.parent.SetRemoteSink(sink)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sink = sink
+}
+
+// Return the remote version last seen for property, 0 when unknown
+func (c *Config) RemoteVersion(name string) int64 {
+	if c.parent != nil {
+		return c.parent.RemoteVersion(c.subPrefix + normalizeKey(name))
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.remoteVersions[normalizeKey(name)]
+}
+
+// pushRemote writes name to the remote sink, if any, and records the new version
+func (c *Config) pushRemote(name string, value string) error {
+	c.mu.RLock()
+	sink := c.sink
+	version := c.remoteVersions[name]
+	c.mu.RUnlock()
+
+	if sink == nil {
+		return nil
+	}
+
+	newVersion, e := sink.Put(name, value, version)
+	if e != nil {
+		return c.scrub(fmt.Errorf(`config: push %s: %w`, name, e))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.remoteVersions == nil {
+		c.remoteVersions = make(map[string]int64)
+	}
+	c.remoteVersions[name] = newVersion
+	return nil
+}
+
+// pushRemoteAll writes the properties of an Update to the remote sink, if
+// any, in one transaction when it is a TxnSink. Other sinks get one Put per
+// key; when one fails, the keys already written are put back to their
+// previous value, as far as their new version still matches.
+func (c *Config) pushRemoteAll(keys []string, values map[string]string) error {
+	c.mu.RLock()
+	sink := c.sink
+	puts := make([]RemotePut, len(keys))
+	old := make(map[string]string, len(keys))
+	for i, k := range keys {
+		puts[i] = RemotePut{Key: k, Value: values[k], Version: c.remoteVersions[k]}
+		if v, ok := c.storage[k]; ok {
+			old[k] = v
+		}
+	}
+	c.mu.RUnlock()
+
+	if sink == nil || len(puts) == 0 {
+		return nil
+	}
+
+	var versions []int64
+	if ts, ok := sink.(TxnSink); ok {
+		var e error
+		if versions, e = ts.PutAll(puts); e != nil {
+			return c.scrub(fmt.Errorf(`config: push %s: %w`, strings.Join(keys, `, `), e))
+		}
+	} else {
+		for i, p := range puts {
+			v, e := sink.Put(p.Key, p.Value, p.Version)
+			if e != nil {
+				c.revertRemote(sink, puts[:i], versions, old)
+				return c.scrub(fmt.Errorf(`config: push %s: %w`, p.Key, e))
+			}
+			versions = append(versions, v)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.remoteVersions == nil {
+		c.remoteVersions = make(map[string]int64)
+	}
+	for i, p := range puts {
+		c.remoteVersions[p.Key] = versions[i]
+	}
+	return nil
+}
+
+// revertRemote puts back the previous values of puts written before a
+// failed push. Keys that did not exist before are left, since a RemoteSink
+// can not delete.
+func (c *Config) revertRemote(sink RemoteSink, puts []RemotePut, versions []int64, old map[string]string) {
+	for i, p := range puts {
+		val, ok := old[p.Key]
+		if !ok {
+			fmt.Println(`Revert remote config failed:`, p.Key, `did not exist before`)
+			continue
+		}
+		v, e := sink.Put(p.Key, val, versions[i])
+		if e != nil {
+			fmt.Println(`Revert remote config failed:`, p.Key, c.scrub(e))
+			continue
+		}
+
+		c.mu.Lock()
+		if c.remoteVersions == nil {
+			c.remoteVersions = make(map[string]int64)
+		}
+		c.remoteVersions[p.Key] = v
+		c.mu.Unlock()
+	}
+}
+
+// seedRemoteVersions records the revisions loaded from RevisionSources,
+// replacing the versions known for the same keys. Caller must hold c.mu.
+func (c *Config) seedRemoteVersions(versions map[string]int64) {
+	if len(versions) == 0 {
+		return
+	}
+	seeded := make(map[string]int64, len(c.remoteVersions)+len(versions))
+	for k, v := range c.remoteVersions {
+		seeded[k] = v
+	}
+	for k, v := range versions {
+		seeded[k] = v
+	}
+	c.remoteVersions = seeded
+}
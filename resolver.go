@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Resolver returns the value of a ${scheme:ref} reference, e.g. a secret
+// read from an internal store
+type Resolver func(ref string) (string, error)
+
+// Register fn for references ${scheme:ref} in config values, e.g.
+// password = ${kms:db/password}. References are resolved when the sources
+// are read, so a Reload resolves them again; a failing resolver fails the
+// load. Properties with resolved references are marked secret. Call before
+// Open.
+func (c *Config) AddResolver(scheme string, fn Resolver) {
+	if c.parent != nil {
+		c.parent.AddResolver(scheme, fn)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// copied so loads in progress keep their resolvers
+	resolvers := make(map[string]Resolver, len(c.resolvers)+1)
+	for k, v := range c.resolvers {
+		resolvers[k] = v
+	}
+	resolvers[strings.ToLower(scheme)] = fn
+	c.resolvers = resolvers
+}
+
+// applyResolvers replaces ${scheme:ref} references of the registered schemes
+// in every value of storage. The properties are marked secret.
+func (c *Config) applyResolvers(storage map[string]string) error {
+	c.mu.RLock()
+	resolvers := c.resolvers
+	c.mu.RUnlock()
+
+	if len(resolvers) == 0 {
+		return nil
+	}
+
+	var keys []string
+	for k, v := range storage {
+		for scheme := range resolvers {
+			if strings.Contains(strings.ToLower(v), `${`+scheme+`:`) {
+				keys = append(keys, k)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var failed error
+		storage[k] = expand(storage[k], func(ref string) (string, bool) {
+			scheme, rest, ok := strings.Cut(ref, `:`)
+			fn := resolvers[strings.ToLower(scheme)]
+			if !ok || fn == nil || failed != nil {
+				return ``, false
+			}
+			val, e := fn(rest)
+			if e != nil {
+				failed = fmt.Errorf(`%s: resolve %s: %v`, k, scheme, e)
+				return ``, false
+			}
+			return val, true
+		})
+		if failed != nil {
+			return failed
+		}
+	}
+
+	c.MarkSecret(keys...)
+	return nil
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind is the declared type of a property
+type Kind string
+
+const (
+	KindString Kind = `string`
+	KindInt    Kind = `int`
+	KindFloat  Kind = `float`
+	KindBool   Kind = `bool`
+)
+
+// Kinds only passed to a CoerceFunc, by GetDuration, GetTime and
+// GetSizeBytes. They can not be declared with DeclareType.
+const (
+	KindDuration Kind = `duration`
+	KindTime     Kind = `time`
+	KindSize     Kind = `size`
+)
+
+// TypeGuess explains how GetAny and GetAllAsInterface typed one property
+type TypeGuess struct {
+	Key      string
+	Kind     Kind
+	Declared bool   // the kind was declared with DeclareType
+	Invalid  bool   // the value does not match the declared kind and is kept as string
+	Value    string // stored text, masked for secret properties
+}
+
+// Declare the type of property name so GetAny and GetAllAsInterface convert
+// it instead of guessing, e.g. DeclareType("user.id", KindString) keeps
+// "007" a string. A * part matches any single key part, as in
+// DeclareType("servers.*.port", KindInt).
+func (c *Config) DeclareType(name string, kind Kind) error {
+	if c.parent != nil {
+		return c.parent.DeclareType(c.subPrefix+normalizeKey(name), kind)
+	}
+
+	switch kind {
+	case KindString, KindInt, KindFloat, KindBool:
+	default:
+		return fmt.Errorf(`config: unknown kind %q`, kind)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.kinds == nil {
+		c.kinds = make(map[string]Kind)
+	}
+	c.kinds[normalizeKey(name)] = kind
+	return nil
+}
+
+// Return all properties as nested maps and arrays like GetAllNested, with
+// values converted to their declared kind or, when not declared, guessed
+// like GetAny
+func (c *Config) GetAllAsInterface() map[string]interface{} {
+	all := c.GetAll()
+	root, prefix := c.root()
+
+	root.mu.RLock()
+	typed := make(map[string]interface{}, len(all))
+	for k, v := range all {
+		typed[k], _ = root.typedValue(prefix+k, v)
+	}
+	root.mu.RUnlock()
+
+	return unflattenValues(typed)
+}
+
+// Explain the type chosen for every property by GetAny and
+// GetAllAsInterface, sorted by key, to find values that were guessed wrong
+func (c *Config) ExplainTypes() []TypeGuess {
+	all := c.GetAll()
+	root, prefix := c.root()
+
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
+	guesses := make([]TypeGuess, 0, len(all))
+	for k, v := range all {
+		_, g := root.typedValue(prefix+k, v)
+		g.Key = k
+		if root.secrets[prefix+k] {
+			g.Value = secretMask
+		}
+		guesses = append(guesses, g)
+	}
+	sort.Slice(guesses, func(i, j int) bool { return guesses[i].Key < guesses[j].Key })
+	return guesses
+}
+
+// typedValue converts val by the declared kind of key, falling back to
+// guessType. Caller must hold c.mu.
+func (c *Config) typedValue(key string, val string) (interface{}, TypeGuess) {
+	g := TypeGuess{Key: key, Value: val}
+
+	kind, ok := c.declaredKind(key)
+	if !ok {
+		v := guessType(val)
+		g.Kind = kindOf(v)
+		return v, g
+	}
+
+	g.Kind, g.Declared = kind, true
+	var v interface{}
+	s, e := c.coercion()(key, val, kind)
+	if e == nil {
+		switch kind {
+		case KindInt:
+			v, e = strconv.ParseInt(s, 10, 64)
+		case KindFloat:
+			v, e = strconv.ParseFloat(s, 64)
+		case KindBool:
+			v, e = strconv.ParseBool(s)
+		default:
+			v = s
+		}
+	}
+	if e != nil {
+		g.Invalid = true
+		return val, g
+	}
+	return v, g
+}
+
+// declaredKind finds the kind declared for key, exact names first. Caller
+// must hold c.mu.
+func (c *Config) declaredKind(key string) (Kind, bool) {
+	if len(c.kinds) == 0 {
+		return ``, false
+	}
+	if kind, ok := c.kinds[key]; ok {
+		return kind, true
+	}
+
+	parts := SplitKey(strings.TrimPrefix(key, "."))
+	for pattern, kind := range c.kinds {
+		if matchKeyPattern(SplitKey(strings.TrimPrefix(pattern, ".")), parts) {
+			return kind, true
+		}
+	}
+	return ``, false
+}
+
+// matchKeyPattern matches key parts against pattern parts where * matches
+// any single part
+func matchKeyPattern(pattern []string, parts []string) bool {
+	if len(pattern) != len(parts) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != `*` && p != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func kindOf(v interface{}) Kind {
+	switch v.(type) {
+	case bool:
+		return KindBool
+	case int64:
+		return KindInt
+	case float64:
+		return KindFloat
+	}
+	return KindString
+}
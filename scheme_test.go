@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenReadsFilesThroughFileSource(t *testing.T) {
+	quietStdout(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, `app.ini`)
+	part := filepath.Join(dir, `part.ini`)
+	if e := os.WriteFile(part, []byte("[db]\nhost = localhost\n"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	if e := os.WriteFile(path, []byte("include part.ini\n[app]\n; the app name\nname = demo\n"), 0600); e != nil {
+		t.Fatal(e)
+	}
+
+	c := &Config{}
+	if e := c.Open(path); e != nil {
+		t.Fatal(e)
+	}
+	if !isFileSource(c.providers[path].src) {
+		t.Fatalf(`Open(%s) source is %T, want *FileSource`, path, c.providers[path].src)
+	}
+	if got := c.GetString(`db.host`); got != `localhost` {
+		t.Errorf(`included db.host = %q`, got)
+	}
+	if got := c.Describe(`app.name`); got != `the app name` {
+		t.Errorf(`Describe(app.name) = %q`, got)
+	}
+	if st := c.SourceStatus(); len(st) != 1 || st[0].Kind != `file` {
+		t.Errorf(`SourceStatus = %+v`, st)
+	}
+
+	u := &Config{}
+	if e := u.Open(`file://` + path); e != nil {
+		t.Fatal(e)
+	}
+	defer u.Close()
+	if got, want := u.GetAll(), c.GetAll(); len(got) != len(want) || got[`app.name`] != want[`app.name`] || got[`db.host`] != want[`db.host`] {
+		t.Errorf(`file:// URL read %v, plain path %v`, got, want)
+	}
+
+	props, e := NewFileSource(path).Load(context.Background())
+	if e != nil {
+		t.Fatal(e)
+	}
+	if props[`db.host`] != `localhost` || props[`app.name`] != `demo` {
+		t.Errorf(`FileSource.Load = %v`, props)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+const secretMask = `******`
+
+// minScrubLength is the length below which secret values are not masked in
+// errors, as such short text also matches unrelated parts of the message
+const minScrubLength = 4
+
+// Mark properties as secret. Their values are replaced with ****** in every
+// error returned by the package, unless shorter than 4 bytes.
+func (c *Config) MarkSecret(names ...string) {
+	if c.parent != nil {
+		c.parent.MarkSecret(c.subNames(names)...)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.secrets == nil {
+		c.secrets = make(map[string]bool)
+	}
+	for _, name := range names {
+		c.secrets[normalizeKey(name)] = true
+	}
+}
+
+// Return true if property was marked with MarkSecret
+func (c *Config) IsSecret(name string) bool {
+	if c.parent != nil {
+		return c.parent.IsSecret(c.subPrefix + normalizeKey(name))
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.secrets[normalizeKey(name)]
+}
+
+// scrub removes the values of secret properties from e. The values are taken
+// from the current properties and from any extra storage (e.g. a config being
+// loaded).
+func (c *Config) scrub(e error, extra ...map[string]string) error {
+	if e == nil {
+		return nil
+	}
+	if c.parent != nil {
+		return c.parent.scrub(e, extra...)
+	}
+
+	c.mu.RLock()
+	var values []string
+	for name := range c.secrets {
+		for _, storage := range append([]map[string]string{c.storage}, extra...) {
+			if val := storage[name]; len(val) >= minScrubLength {
+				values = append(values, val)
+			}
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(values) == 0 {
+		return e
+	}
+
+	// longest first so a secret containing another one is fully masked
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	msg := e.Error()
+	scrubbed := msg
+	for _, val := range values {
+		scrubbed = strings.ReplaceAll(scrubbed, val, secretMask)
+	}
+	if scrubbed == msg {
+		return e
+	}
+	return &scrubbedError{msg: scrubbed, err: e}
+}
+
+// scrubbedError is an error with the values of secret properties masked. It
+// still matches the error it wraps with errors.Is and errors.As.
+type scrubbedError struct {
+	msg string
+	err error
+}
+
+func (e *scrubbedError) Error() string {
+	return e.msg
+}
+
+func (e *scrubbedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestScrubKeepsWrappedError(t *testing.T) {
+	c := &Config{}
+	c.LoadMap(map[string]string{`db.password`: `hunter22`, `db.pin`: `42`}, MapOptions{})
+	c.MarkSecret(`db.password`, `db.pin`)
+
+	for _, target := range []error{ErrKeyNotFound, ErrConflict} {
+		e := c.scrub(fmt.Errorf(`%w: value hunter22`, target))
+		if strings.Contains(e.Error(), `hunter22`) || !strings.Contains(e.Error(), secretMask) {
+			t.Errorf(`scrub = %q, want the secret masked`, e)
+		}
+		if !errors.Is(e, target) {
+			t.Errorf(`errors.Is(%v, %v) = false after scrub`, e, target)
+		}
+	}
+
+	verr := &ValidationError{Problems: []Problem{{Check: `validator`, Err: errors.New(`bad hunter22`)}}}
+	var got *ValidationError
+	if e := c.scrub(verr); !errors.As(e, &got) || got != verr {
+		t.Errorf(`errors.As(%v, *ValidationError) failed after scrub`, e)
+	}
+}
+
+func TestScrubSkipsShortSecrets(t *testing.T) {
+	c := &Config{}
+	c.LoadMap(map[string]string{`db.pin`: `42`}, MapOptions{})
+	c.MarkSecret(`db.pin`)
+
+	e := errors.New(`listen :8042 failed`)
+	if got := c.scrub(e); got != e {
+		t.Errorf(`scrub = %q, want the error unchanged`, got)
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+const fileRefSuffix = `_file`
+
+// Enable the _FILE convention of Docker and Kubernetes secrets: a property
+// database.password_file, or the environment variable bound to
+// database.password with _FILE appended, names a file whose content becomes
+// database.password. The files are read on every load and their properties
+// are marked secret. A missing file is an error only when the property is
+// declared with DeclareType or required with Require. Off by default, since
+// ordinary settings such as log.output_file end in _file too. Call before
+// Open.
+func (c *Config) SetFileRefs(enable bool) {
+	if c.parent != nil {
+		c.parent.SetFileRefs(enable)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fileRefs = enable
+}
+
+// readFileRefs replaces the properties referenced as enabled by SetFileRefs
+// with the content of their files
+func (c *Config) readFileRefs(storage map[string]string) error {
+	c.mu.RLock()
+	if !c.fileRefs {
+		c.mu.RUnlock()
+		return nil
+	}
+	fsys := c.filesystem()
+	refs := make(map[string]string)
+	for name, env := range c.envBindings {
+		if path := os.Getenv(env + `_FILE`); path != `` {
+			refs[name] = path
+		}
+	}
+	for _, prefix := range c.envPrefixes {
+		for _, kv := range os.Environ() {
+			name, path, _ := strings.Cut(kv, "=")
+			if path == `` || !strings.HasPrefix(name, prefix+"_") || !strings.HasSuffix(name, `_FILE`) {
+				continue
+			}
+			key := DefaultEnvKey(strings.TrimSuffix(name[len(prefix)+1:], `_FILE`))
+			if key != `` {
+				refs[key] = path
+			}
+		}
+	}
+	needed := make(map[string]bool)
+	for name := range refs {
+		needed[name] = false
+	}
+	for k, path := range storage {
+		if strings.HasSuffix(k, fileRefSuffix) && len(k) > len(fileRefSuffix) && path != `` {
+			needed[strings.TrimSuffix(k, fileRefSuffix)] = false
+		}
+	}
+	for name := range needed {
+		_, declared := c.declaredKind(name)
+		needed[name] = declared || contains(c.required, name)
+	}
+	c.mu.RUnlock()
+
+	for k, path := range storage {
+		if strings.HasSuffix(k, fileRefSuffix) && len(k) > len(fileRefSuffix) && path != `` {
+			name := strings.TrimSuffix(k, fileRefSuffix)
+			if _, ok := refs[name]; !ok {
+				refs[name] = path // environment wins over config files
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(refs))
+	for name, path := range refs {
+		data, e := readFile(fsys, path)
+		if errors.Is(e, fs.ErrNotExist) && !needed[name] {
+			continue
+		}
+		if e != nil {
+			return fmt.Errorf(`%s: %v`, name+fileRefSuffix, e)
+		}
+		storage[name] = strings.TrimRight(string(data), "\r\n")
+		names = append(names, name)
+	}
+
+	c.MarkSecret(names...)
+	return nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretsManagerSource reads secrets from AWS Secrets Manager. A JSON secret
+// is flattened under the prefix of its mount, e.g. field password of
+// prod/db mounted at db. is db.password; any other secret becomes the
+// property named by the prefix. All properties are marked secret.
+type SecretsManagerSource struct {
+	Region      string // AWS_REGION by default
+	Endpoint    string // https://secretsmanager.<region>.amazonaws.com by default
+	Credentials AWSCredentials
+	Client      *http.Client
+
+	// Interval between checks for a rotated secret. Secrets are read again
+	// only when their current version changed. Zero disables the checks.
+	Interval time.Duration
+
+	mounts []vaultMount
+
+	mu       sync.Mutex
+	versions map[string]string // secret id to loaded AWSCURRENT version
+}
+
+// Create source for Secrets Manager, checked for rotation every minute
+func NewSecretsManagerSource() *SecretsManagerSource {
+	return &SecretsManagerSource{Interval: time.Minute}
+}
+
+// Read the secret with id (name or ARN) into properties under prefix (e.g.
+// db.)
+func (s *SecretsManagerSource) Mount(id string, prefix string) *SecretsManagerSource {
+	s.mounts = append(s.mounts, vaultMount{path: id, prefix: prefix})
+	return s
+}
+
+// IsSecret reports that every property of the source is secret
+func (s *SecretsManagerSource) IsSecret(name string) bool {
+	return true
+}
+
+// Load reads the current version of every mounted secret
+func (s *SecretsManagerSource) Load(ctx context.Context) (map[string]string, error) {
+	client, e := s.client()
+	if e != nil {
+		return nil, e
+	}
+
+	props := make(map[string]string)
+	versions := make(map[string]string)
+	for _, m := range s.mounts {
+		var resp struct {
+			SecretString string
+			VersionId    string
+		}
+		if e := client.call(ctx, `secretsmanager.GetSecretValue`, map[string]string{"SecretId": m.path}, &resp); e != nil {
+			return nil, e
+		}
+		versions[m.path] = resp.VersionId
+
+		prefix := strings.TrimSuffix(normalizeKey(m.prefix), ".")
+		var doc map[string]interface{}
+		if json.Unmarshal([]byte(resp.SecretString), &doc) != nil {
+			props[prefix] = resp.SecretString
+			continue
+		}
+		for k, v := range Flatten(doc) {
+			props[joinKey(prefix, k)] = v
+		}
+	}
+
+	s.mu.Lock()
+	s.versions = versions
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Watch calls notify when the current version of a secret changed, e.g.
+// after a rotation
+func (s *SecretsManagerSource) Watch(ctx context.Context, notify func()) error {
+	return pollWatch(ctx, s.Interval, func() {
+		if s.rotated(ctx) {
+			notify()
+		}
+	})
+}
+
+func (s *SecretsManagerSource) pollInterval() time.Duration {
+	return s.Interval
+}
+
+// rotated returns true when a secret has a current version other than the
+// one loaded. Errors are reported as rotated so the reload records them.
+func (s *SecretsManagerSource) rotated(ctx context.Context) bool {
+	client, e := s.client()
+	if e != nil {
+		return true
+	}
+
+	s.mu.Lock()
+	versions := s.versions
+	s.mu.Unlock()
+
+	for _, m := range s.mounts {
+		var resp struct {
+			VersionIdsToStages map[string][]string
+		}
+		if e := client.call(ctx, `secretsmanager.DescribeSecret`, map[string]string{"SecretId": m.path}, &resp); e != nil {
+			return ctx.Err() == nil
+		}
+		for version, stages := range resp.VersionIdsToStages {
+			if contains(stages, `AWSCURRENT`) && version != versions[m.path] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *SecretsManagerSource) client() (*awsClient, error) {
+	return newAWSClient(`secretsmanager`, s.Region, s.Endpoint, s.Credentials, s.Client)
+}
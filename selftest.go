@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Problem is one issue found by SelfTest
+type Problem struct {
+	Check string // parse, secret, required, type, reference or validator
+	Key   string
+	Err   error
+}
+
+// SelfTestReport is the result of SelfTest
+type SelfTestReport struct {
+	Files    []string
+	Keys     int
+	Problems []Problem
+}
+
+// Return true when SelfTest found no problem
+func (r *SelfTestReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *SelfTestReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d files, %d properties\n", len(r.Files), r.Keys)
+	for _, p := range r.Problems {
+		if p.Key != `` {
+			fmt.Fprintf(&b, "%s: %s: %v\n", p.Check, p.Key, p.Err)
+		} else {
+			fmt.Fprintf(&b, "%s: %v\n", p.Check, p.Err)
+		}
+	}
+	if r.OK() {
+		b.WriteString("OK\n")
+	}
+	return b.String()
+}
+
+// Mark properties that must be set. SelfTest reports the missing ones.
+func (c *Config) Require(names ...string) {
+	if c.parent != nil {
+		c.parent.Require(c.subNames(names)...)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, name := range names {
+		c.required = append(c.required, normalizeKey(name))
+	}
+}
+
+// Register function checking the config, run by SelfTest against the
+// properties it read
+func (c *Config) AddValidator(fn func(c *Config) error) {
+	if c.parent != nil {
+		prefix := strings.TrimSuffix(c.subPrefix, ".")
+		c.parent.AddValidator(func(p *Config) error {
+			return fn(p.Sub(prefix))
+		})
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.validators = append(c.validators, fn)
+}
+
+// Read all sources again without changing the current properties and check
+// the result: parse errors, required properties, declared types, references
+// left unresolved by SetExpandEnv or SetInterpolate, and validators. Meant
+// for a --config-check flag run by deploy pipelines:
+//
+//	if *configCheck {
+//		r := cfg.SelfTest()
+//		fmt.Print(r)
+//		if !r.OK() {
+//			os.Exit(1)
+//		}
+//		os.Exit(0)
+//	}
+func (c *Config) SelfTest() *SelfTestReport {
+	if c.parent != nil {
+		return c.parent.SelfTest()
+	}
+
+	r := &SelfTestReport{}
+
+	c.mu.RLock()
+	set := c.sourceSet()
+	c.mu.RUnlock()
+
+	if len(set.names) == 0 {
+		r.Problems = append(r.Problems, Problem{Check: `parse`, Err: ErrNotOpened})
+		return r
+	}
+
+	tmp, _, e := c.readSources(set)
+	if e != nil {
+		r.Problems = append(r.Problems, Problem{Check: `parse`, Err: e})
+		return r
+	}
+
+	if e := c.readRefs(tmp.storage); e != nil {
+		r.Problems = append(r.Problems, Problem{Check: `secret`, Err: c.scrub(e, tmp.storage)})
+	}
+
+	raw := copyStorage(tmp.storage)
+	view := c.preview(tmp.storage)
+	all := view.GetAll()
+	r.Files = tmp.file
+	r.Keys = len(all)
+
+	c.mu.RLock()
+	required := append([]string(nil), c.required...)
+	validators := append([]func(*Config) error(nil), c.validators...)
+	for _, ns := range c.namespaces {
+		validators = append(validators, ns.validators...)
+	}
+	checkRefs := c.expandEnv || c.interpolate
+	c.mu.RUnlock()
+
+	for _, name := range required {
+		if _, ok := view.lookupRaw(name); !ok {
+			r.Problems = append(r.Problems, Problem{Check: `required`, Key: name, Err: ErrKeyNotFound})
+		}
+	}
+
+	for _, g := range view.ExplainTypes() {
+		if g.Invalid {
+			r.Problems = append(r.Problems, Problem{Check: `type`, Key: g.Key, Err: c.invalidType(g)})
+		}
+	}
+
+	if checkRefs {
+		keys := make([]string, 0, len(all))
+		for k := range all {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			// $$ is a literal $, so ${ in the result may be intended
+			if val := view.GetString(k); strings.Contains(val, `${`) && !strings.Contains(raw[k], `$$`) {
+				r.Problems = append(r.Problems, Problem{Check: `reference`, Key: k, Err: view.scrub(errors.New(`unresolved reference in ` + val))})
+			}
+		}
+	}
+
+	for _, fn := range validators {
+		if e := fn(view); e != nil {
+			r.Problems = append(r.Problems, Problem{Check: `validator`, Err: view.scrub(e)})
+		}
+	}
+	return r
+}
+
+// preview returns a Config holding storage, resolved and read like the
+// current properties, without any source or watcher
+func (c *Config) preview(storage map[string]string) *Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	c.resolve(storage)
+	return c.view(storage)
+}
+
+// view returns a Config holding storage as it is, read like the current
+// properties. Caller must hold c.mu.
+func (c *Config) view(storage map[string]string) *Config {
+	return &Config{
+		storage:     storage,
+		secrets:     c.secrets,
+		envPrefixes: c.envPrefixes,
+		envBindings: c.envBindings,
+		interpolate: c.interpolate,
+		numbers:     c.numbers,
+		location:    c.location,
+		kinds:       c.kinds,
+	}
+}
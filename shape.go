@@ -0,0 +1,109 @@
+package config
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Shape describes a node of the property tree built from the dotted names
+type Shape struct {
+	Kind     string            // object, array or scalar
+	Len      int               // number of children of an object or array
+	Children map[string]*Shape // by key part, array elements by index
+}
+
+// Return the structure of the current properties: which names are objects,
+// arrays (keys 0..n-1) or scalars, as Unflatten sees them
+func (c *Config) Shape() *Shape {
+	return shapeOf(Unflatten(c.GetAll()))
+}
+
+// Return the names of all arrays, e.g. servers and servers.0.ports, sorted
+func (c *Config) ArrayPrefixes() []string {
+	var out []string
+	c.Shape().walk(``, func(key string, s *Shape) {
+		if s.Kind == `array` {
+			out = append(out, key)
+		}
+	})
+	sort.Strings(out)
+	return out
+}
+
+func shapeOf(v interface{}) *Shape {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		s := &Shape{Kind: `object`, Len: len(val), Children: make(map[string]*Shape, len(val))}
+		for k, child := range val {
+			s.Children[k] = shapeOf(child)
+		}
+		return s
+	case []interface{}:
+		s := &Shape{Kind: `array`, Len: len(val), Children: make(map[string]*Shape, len(val))}
+		for i, child := range val {
+			s.Children[strconv.Itoa(i)] = shapeOf(child)
+		}
+		return s
+	}
+	return &Shape{Kind: `scalar`}
+}
+
+// Return the node of property key, nil when there is none
+func (s *Shape) Lookup(key string) *Shape {
+	node := s
+	for _, part := range SplitKey(strings.TrimPrefix(normalizeKey(key), ".")) {
+		if node == nil {
+			return nil
+		}
+		node = node.Children[part]
+	}
+	return node
+}
+
+// walk calls fn for every node below s with its property name
+func (s *Shape) walk(prefix string, fn func(key string, s *Shape)) {
+	for _, k := range s.keys() {
+		child := s.Children[k]
+		key := joinKey(prefix, EscapeKey(k))
+		fn(key, child)
+		child.walk(key, fn)
+	}
+}
+
+// keys returns the child names, array indexes in numeric order
+func (s *Shape) keys() []string {
+	keys := make([]string, 0, len(s.Children))
+	for k := range s.Children {
+		keys = append(keys, k)
+	}
+	if s.Kind == `array` {
+		sort.Slice(keys, func(i, j int) bool {
+			a, _ := strconv.Atoi(keys[i])
+			b, _ := strconv.Atoi(keys[j])
+			return a < b
+		})
+	} else {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+// String renders the tree one node per line, indented by depth
+func (s *Shape) String() string {
+	var b strings.Builder
+	s.render(&b, ``)
+	return b.String()
+}
+
+func (s *Shape) render(b *strings.Builder, indent string) {
+	for _, k := range s.keys() {
+		child := s.Children[k]
+		b.WriteString(indent + EscapeKey(k))
+		if child.Kind != `scalar` {
+			b.WriteString(` (` + child.Kind + `, ` + strconv.Itoa(child.Len) + `)`)
+		}
+		b.WriteString("\n")
+		child.render(b, indent+`  `)
+	}
+}
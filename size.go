@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// size units, matched case-insensitively
+var sizeUnits = map[string]float64{
+	``:    1,
+	`b`:   1,
+	`kb`:  1e3,
+	`mb`:  1e6,
+	`gb`:  1e9,
+	`tb`:  1e12,
+	`pb`:  1e15,
+	`k`:   1 << 10,
+	`m`:   1 << 20,
+	`g`:   1 << 30,
+	`t`:   1 << 40,
+	`p`:   1 << 50,
+	`kib`: 1 << 10,
+	`mib`: 1 << 20,
+	`gib`: 1 << 30,
+	`tib`: 1 << 40,
+	`pib`: 1 << 50,
+}
+
+// Read size property in bytes. If property is not exists or invalid will
+// return 0
+func (c *Config) GetSizeBytes(name string) int64 {
+	return c.GetSizeBytesOr(name, 0)
+}
+
+// Read size property in bytes or return defValue if property is not exists
+// or invalid
+func (c *Config) GetSizeBytesOr(name string, defValue int64) int64 {
+	v, e := c.GetSizeBytesE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read size property in bytes, returns an error if property is not exists
+// or invalid. Values are a number with an optional unit: B, the decimal
+// KB, MB, GB, TB and PB (powers of 1000), the binary KiB, MiB, GiB, TiB and
+// PiB, or K, M, G, T and P as short binary units (powers of 1024), e.g.
+// 10MB, 512KiB, 2G or 1.5 GB.
+func (c *Config) GetSizeBytesE(name string) (int64, error) {
+	var v int64
+	e := c.parseKind(name, KindSize, func(s string) (e error) {
+		v, e = parseSize(s)
+		return e
+	})
+	return v, e
+}
+
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+		end++
+	}
+	if end == 0 {
+		return 0, errors.New(`invalid size ` + strconv.Quote(s))
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[end:]))]
+	if !ok {
+		return 0, errors.New(`unknown size unit in ` + strconv.Quote(s))
+	}
+	if n, e := strconv.ParseInt(s[:end], 10, 64); e == nil && unit == 1 {
+		return n, nil
+	}
+
+	n, e := strconv.ParseFloat(s[:end], 64)
+	if e != nil {
+		return 0, errors.New(`invalid size ` + strconv.Quote(s))
+	}
+	bytes := math.Round(n * unit)
+	if bytes >= math.MaxInt64 {
+		return 0, errors.New(`size out of range ` + strconv.Quote(s))
+	}
+	return int64(bytes), nil
+}
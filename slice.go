@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Set the separator GetStringSlice splits single values on. Default ",".
+func (c *Config) SetSliceSeparator(sep string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sliceSep = sep
+}
+
+// Read string slice property. If property is not exists will return nil
+func (c *Config) GetStringSlice(name string) []string {
+	return c.GetStringSliceOr(name, nil)
+}
+
+// Read string slice property or return defValue if property is not exists
+func (c *Config) GetStringSliceOr(name string, defValue []string) []string {
+	v, e := c.GetStringSliceE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read string slice property, returns an error if property is not exists.
+// Arrays (name.0, name.1, ... from JSON, YAML or name[] in INI) are read as
+// they are; a single value such as "a, b, c" is split on the separator set
+// with SetSliceSeparator and its items trimmed, empty items are dropped.
+func (c *Config) GetStringSliceE(name string) ([]string, error) {
+	name = normalizeKey(name)
+
+	var items []string
+	for i := 0; ; i++ {
+		v, ok := c.lookup(name + "." + strconv.Itoa(i))
+		if !ok {
+			break
+		}
+		items = append(items, v)
+	}
+	if items != nil {
+		return items, nil
+	}
+
+	val, ok := c.lookup(name)
+	if !ok {
+		return nil, c.notFound(name)
+	}
+
+	c.mu.RLock()
+	sep := c.sliceSep
+	c.mu.RUnlock()
+	if sep == `` {
+		sep = `,`
+	}
+
+	items = []string{}
+	for _, item := range strings.Split(val, sep) {
+		if item = strings.TrimSpace(item); item != `` {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// Read int slice property. If property is not exists or an item is invalid
+// will return nil
+func (c *Config) GetIntSlice(name string) []int {
+	return c.GetIntSliceOr(name, nil)
+}
+
+// Read int slice property or return defValue if property is not exists or
+// an item is invalid
+func (c *Config) GetIntSliceOr(name string, defValue []int) []int {
+	v, e := c.GetIntSliceE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read int slice property like GetStringSliceE, returns an error if
+// property is not exists or an item is not an integer
+func (c *Config) GetIntSliceE(name string) ([]int, error) {
+	items, e := c.GetStringSliceE(name)
+	if e != nil {
+		return nil, e
+	}
+
+	c.mu.RLock()
+	coerce := c.coercion()
+	c.mu.RUnlock()
+
+	key := normalizeKey(name)
+	out := make([]int, len(items))
+	for i, item := range items {
+		if item, e = coerce(key, item, KindInt); e != nil {
+			return nil, c.sliceError(name, i, e)
+		}
+		if out[i], e = strconv.Atoi(item); e != nil {
+			return nil, c.sliceError(name, i, e)
+		}
+	}
+	return out, nil
+}
+
+// Read float64 slice property. If property is not exists or an item is
+// invalid will return nil
+func (c *Config) GetFloatSlice(name string) []float64 {
+	return c.GetFloatSliceOr(name, nil)
+}
+
+// Read float64 slice property or return defValue if property is not exists
+// or an item is invalid
+func (c *Config) GetFloatSliceOr(name string, defValue []float64) []float64 {
+	v, e := c.GetFloatSliceE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read float64 slice property like GetStringSliceE, returns an error if
+// property is not exists or an item is not a number. Separators set with
+// SetNumberFormat are accepted, so pick a slice separator that differs.
+func (c *Config) GetFloatSliceE(name string) ([]float64, error) {
+	items, e := c.GetStringSliceE(name)
+	if e != nil {
+		return nil, e
+	}
+
+	c.mu.RLock()
+	numbers := c.numbers
+	coerce := c.coercion()
+	c.mu.RUnlock()
+
+	key := normalizeKey(name)
+	out := make([]float64, len(items))
+	for i, item := range items {
+		if item, e = coerce(key, item, KindFloat); e != nil {
+			return nil, c.sliceError(name, i, e)
+		}
+		if out[i], e = numbers.parseFloat(item, 64); e != nil {
+			return nil, c.sliceError(name, i, e)
+		}
+	}
+	return out, nil
+}
+
+// sliceError wraps the error of item i of slice property name
+func (c *Config) sliceError(name string, i int, e error) error {
+	return c.scrub(fmt.Errorf(`config: %s[%d]: %w`, normalizeKey(name), i, e))
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// snapshotVersion changes when the snapshot layout or the parsers change
+const snapshotVersion = 2
+
+type snapshot struct {
+	Sources  []string
+	Settings string // parseSettings when the snapshot was written
+	Files    []snapshotFile
+	Globs    map[string][]string // include patterns and their matches
+	Dirs     map[string][]string // OpenDir sources and their files
+	Storage  map[string]string
+
+	Origins      map[string]string
+	Descriptions map[string]string
+	EmptyFiles   []string
+	Secrets      []string // properties marked secret, stored by WriteCompiled
+}
+
+type snapshotFile struct {
+	Name    string
+	ModTime int64
+	Size    int64
+}
+
+// Keep a binary snapshot of the parsed properties in path. When every file
+// read by the previous parse (including includes) is unchanged, include
+// patterns and OpenDir sources match the same files and the parse settings
+// are the same, Open loads the snapshot instead of parsing the config files
+// again. Call before Open.
+func (c *Config) UseSnapshot(path string) {
+	if c.parent != nil {
+		c.parent.UseSnapshot(path)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.snapshotPath = path
+}
+
+// parseSettings returns the settings changing how files are parsed, stored
+// with a snapshot so changing one of them parses the files again. Caller
+// must hold c.mu.
+func (c *Config) parseSettings() string {
+	formats := make([]string, 0, len(c.formats))
+	for ext, format := range c.formats {
+		formats = append(formats, ext+"="+format)
+	}
+	sort.Strings(formats)
+
+	envKey := ``
+	if c.envKey != nil {
+		envKey = runtime.FuncForPC(reflect.ValueOf(c.envKey).Pointer()).Name()
+	}
+	return fmt.Sprintf(`v%d compat=%t strict=%t repeated=%t comma=%t merge=%d formats=%s envkey=%s`,
+		snapshotVersion, c.iniCompat, c.iniStrict, c.repeatedKeyArrays, c.iniCommaArrays,
+		c.arrayMerge, strings.Join(formats, ","), envKey)
+}
+
+// loadSnapshot returns the stored properties if the snapshot is still valid
+func loadSnapshot(fsys FS, path string, set sourceSet, settings string) (*snapshot, bool) {
+	fi, e := fsys.Open(path)
+	if e != nil {
+		return nil, false
+	}
+	defer fi.Close()
+
+	var s snapshot
+	if e := gob.NewDecoder(fi).Decode(&s); e != nil {
+		return nil, false
+	}
+
+	if s.Settings != settings || !equalStrings(s.Sources, set.names) {
+		return nil, false
+	}
+
+	for _, f := range s.Files {
+		st, e := fsys.Stat(f.Name)
+		if e != nil || st.ModTime().UnixNano() != f.ModTime || st.Size() != f.Size {
+			return nil, false
+		}
+	}
+
+	// a file added to or removed from a directory does not change the
+	// files read before, so patterns are matched again
+	for pattern, matches := range s.Globs {
+		found, e := fsys.Glob(pattern)
+		sort.Strings(found)
+		if e != nil || !equalStrings(found, matches) {
+			return nil, false
+		}
+	}
+	for source, files := range s.Dirs {
+		if !equalStrings(sourceFiles(fsys, source, set.dirs), files) {
+			return nil, false
+		}
+	}
+	return &s, true
+}
+
+// writeSnapshot stores the properties read into tmp from the sources of set
+func writeSnapshot(fsys FS, path string, set sourceSet, settings string, tmp *Config) error {
+	s := snapshot{
+		Sources:      set.names,
+		Settings:     settings,
+		Globs:        tmp.includeGlobs,
+		Storage:      tmp.storage,
+		Origins:      tmp.origins,
+		Descriptions: tmp.descriptions,
+		EmptyFiles:   tmp.emptyFiles,
+	}
+
+	files := tmp.file
+	for source, dir := range set.dirs {
+		if s.Dirs == nil {
+			s.Dirs = make(map[string][]string)
+		}
+		s.Dirs[source] = sourceFiles(fsys, source, set.dirs)
+		files = append(files, dir)
+	}
+	for _, name := range files {
+		if contains(s.filenames(), name) {
+			continue // includes are listed twice
+		}
+		st, e := fsys.Stat(name)
+		if e != nil {
+			return e
+		}
+		s.Files = append(s.Files, snapshotFile{Name: name, ModTime: st.ModTime().UnixNano(), Size: st.Size()})
+	}
+	return writeGob(fsys, path, &s)
+}
+
+// writeGob atomically replaces path with the gob encoding of v
+func writeGob(fsys FS, path string, v interface{}) error {
+	var buf bytes.Buffer
+	if e := gob.NewEncoder(&buf).Encode(v); e != nil {
+		return e
+	}
+	return writeFile(fsys, path, buf.Bytes())
+}
+
+// equalStrings reports whether a and b hold the same strings in the same order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *snapshot) filenames() []string {
+	names := make([]string, len(s.Files))
+	for i, f := range s.Files {
+		names[i] = f.Name
+	}
+	return names
+}
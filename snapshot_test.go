@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openSnapshot opens paths with a snapshot in dir and reports whether the
+// snapshot was used, seen by the snapshot being left unchanged
+func openSnapshot(t *testing.T, dir string, setup func(c *Config), paths ...string) (*Config, bool) {
+	t.Helper()
+
+	snap := filepath.Join(dir, `config.snap`)
+	before, _ := os.ReadFile(snap)
+
+	c := &Config{}
+	c.UseSnapshot(snap)
+	if setup != nil {
+		setup(c)
+	}
+	if e := c.Open(paths...); e != nil {
+		t.Fatal(e)
+	}
+
+	after, e := os.ReadFile(snap)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return c, before != nil && string(before) == string(after)
+}
+
+func TestSnapshot(t *testing.T) {
+	quietStdout(t)
+
+	dir := t.TempDir()
+	path, empty := filepath.Join(dir, `app.ini`), filepath.Join(dir, `empty.ini`)
+	write := func(name, data string) {
+		t.Helper()
+		if e := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e := os.Mkdir(filepath.Join(dir, `conf.d`), 0700); e != nil {
+		t.Fatal(e)
+	}
+	write(`app.ini`, "include conf.d/*.conf\n[app]\n; the app name\nname = demo\n")
+	write(`conf.d/a.conf`, "[a]\nx = 1\n")
+	write(`empty.ini`, "; nothing here\n")
+
+	if _, used := openSnapshot(t, dir, nil, path, empty); used {
+		t.Fatal(`first Open used a snapshot`)
+	}
+
+	c, used := openSnapshot(t, dir, nil, path, empty)
+	if !used {
+		t.Fatal(`second Open parsed the files again`)
+	}
+	if got := c.Describe(`app.name`); got != `the app name` {
+		t.Errorf(`Describe(app.name) from the snapshot = %q`, got)
+	}
+	if got := c.Origin(`a.x`); got != path {
+		t.Errorf(`Origin(a.x) from the snapshot = %q`, got)
+	}
+	if got := c.Summary().EmptyFiles; len(got) != 1 || got[0] != empty {
+		t.Errorf(`EmptyFiles from the snapshot = %v`, got)
+	}
+
+	write(`conf.d/b.conf`, "[b]\ny = 2\n")
+	c, used = openSnapshot(t, dir, nil, path, empty)
+	if used || c.GetString(`b.y`) != `2` {
+		t.Errorf(`file added to an include pattern: snapshot used %v, b.y = %q`, used, c.GetString(`b.y`))
+	}
+
+	if _, used = openSnapshot(t, dir, func(c *Config) { c.SetIniCompat(true) }, path, empty); used {
+		t.Error(`snapshot used after SetIniCompat changed`)
+	}
+	if _, used = openSnapshot(t, dir, func(c *Config) { c.SetRepeatedKeysAsArray(true) }, path, empty); used {
+		t.Error(`snapshot used after SetRepeatedKeysAsArray changed`)
+	}
+}
@@ -0,0 +1,109 @@
+//go:build soak
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// Run with: go test -tags soak -run Soak -soak.cycles 100000
+var soakCycles = flag.Int(`soak.cycles`, 10000, `Open/Watch/Reload/Close cycles run by TestSoak`)
+
+// TestSoak opens, watches, reloads and closes configs over and over and
+// checks that no goroutine is left running and that watched files and heap
+// return to where they started.
+func TestSoak(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	quietStdout(t)
+	SetWatchInterval(time.Millisecond)
+	defer SetWatchInterval(time.Second)
+
+	dir := t.TempDir()
+	main := filepath.Join(dir, `app.ini`)
+	part := filepath.Join(dir, `part.ini`)
+	writeSoakFile(t, part, "[part]\nkey = 0\n")
+	writeSoakFile(t, main, "include part.ini\n[app]\nkey = 0\n")
+
+	cycle := func(i int) {
+		c := &Config{}
+		if e := c.Open(main); e != nil {
+			t.Fatal(e)
+		}
+		if e := c.Watch(); e != nil {
+			t.Fatal(e)
+		}
+		c.OnReload(func() {})
+		c.WatchPrefix(`app`, func([]Change) {})
+
+		writeSoakFile(t, part, fmt.Sprintf("[part]\nkey = %d\n", i))
+		if e := c.Reload(); e != nil {
+			t.Fatal(e)
+		}
+		if got := c.GetInt(`part.key`); got != i {
+			t.Fatalf(`cycle %d: part.key = %d`, i, got)
+		}
+		c.GetAllNested()
+
+		if e := c.Close(); e != nil {
+			t.Fatal(e)
+		}
+		if e := c.Reload(); e == nil {
+			t.Fatalf(`cycle %d: Reload after Close succeeded`, i)
+		}
+	}
+
+	// warm up lazily created state before taking the baseline
+	cycle(0)
+	heap := heapInUse()
+
+	for i := 1; i <= *soakCycles; i++ {
+		cycle(i)
+		if i%100 == 0 {
+			checkWatcherIdle(t, i)
+		}
+	}
+	checkWatcherIdle(t, *soakCycles)
+
+	if grown := int64(heapInUse()) - int64(heap); grown > 4<<20 {
+		t.Errorf(`heap grew by %d bytes over %d cycles`, grown, *soakCycles)
+	}
+}
+
+func checkWatcherIdle(t *testing.T, cycle int) {
+	t.Helper()
+
+	sharedWatcher.mu.Lock()
+	files, stop := len(sharedWatcher.files), sharedWatcher.stop
+	sharedWatcher.mu.Unlock()
+
+	if files != 0 {
+		t.Fatalf(`cycle %d: %d files still watched after Close`, cycle, files)
+	}
+	if stop != nil {
+		t.Fatalf(`cycle %d: watcher goroutine still running after Close`, cycle)
+	}
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapInuse
+}
+
+func writeSoakFile(t *testing.T, path string, data string) {
+	t.Helper()
+
+	if e := os.WriteFile(path, []byte(data), 0600); e != nil {
+		t.Fatal(e)
+	}
+}
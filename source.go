@@ -0,0 +1,170 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Source provides properties from outside the file system, e.g. etcd or a
+// database table
+type Source interface {
+	// Load returns all properties of the source keyed by property name
+	Load(ctx context.Context) (map[string]string, error)
+	// Watch blocks until ctx is done, calling notify whenever the properties
+	// may have changed. Sources that can not watch return nil at once.
+	Watch(ctx context.Context, notify func()) error
+}
+
+// SecretSource is a Source that knows which of its properties are secret,
+// e.g. Vault. They are marked with MarkSecret on every load.
+type SecretSource interface {
+	Source
+	IsSecret(name string) bool
+}
+
+// VersionedSource is a Source that can tell the version of the properties it
+// loaded last, reported by SourceStatus
+type VersionedSource interface {
+	Source
+	Version() string
+}
+
+// RevisionSource is a Source that knows the remote revision of every
+// property it loaded last, e.g. the etcd mod_revision. They are the versions
+// compared when a RemoteSink writes the same keys.
+type RevisionSource interface {
+	Source
+	Revisions() map[string]int64
+}
+
+// polledSource is a Source whose Watch polls, reported by SourceStatus
+type polledSource interface {
+	pollInterval() time.Duration
+}
+
+type provider struct {
+	src    Source
+	cancel context.CancelFunc
+}
+
+// Read properties from src, merged after the sources already opened. name
+// identifies the source in errors and SourceStatus. Changes reported by the
+// source's Watch are applied with Reload until Close.
+func (c *Config) AddSource(name string, src Source) error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	if name == `` || src == nil {
+		return errors.New(`config: source needs a name`)
+	}
+	if e := c.checkNotClosed(); e != nil {
+		return e
+	}
+
+	c.mu.Lock()
+	if _, ok := c.providers[name]; ok || contains(c.sources, name) {
+		c.mu.Unlock()
+		return errors.New(`config: source already opened: ` + name)
+	}
+	if c.providers == nil {
+		c.providers = make(map[string]*provider)
+	}
+	p := &provider{src: src}
+	c.providers[name] = p
+	sources := c.sources
+	c.sources = append(append([]string(nil), sources...), name)
+	c.mu.Unlock()
+
+	if _, e := c.load(); e != nil {
+		c.mu.Lock()
+		c.sources = sources
+		delete(c.providers, name)
+		c.mu.Unlock()
+		return e
+	}
+
+	c.startProvider(name, p)
+	return nil
+}
+
+// startProvider runs the Watch of p until stopProviders
+func (c *Config) startProvider(name string, p *provider) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	p.cancel = cancel
+	c.mu.Unlock()
+
+	go c.watchSource(ctx, name, p.src)
+}
+
+// watchSource runs the Watch of src, restarting it after errors
+func (c *Config) watchSource(ctx context.Context, name string, src Source) {
+	notify := func() {
+		if e := c.Reload(); e != nil {
+			fmt.Println(`Reload config failed:`, e)
+		}
+	}
+
+	for {
+		e := src.Watch(ctx, notify)
+		if e == nil || ctx.Err() != nil {
+			return
+		}
+
+		fmt.Println(`Watch config source failed:`, name, e)
+		c.recordSources([]string{name}, name, e)
+
+		c.mu.RLock()
+		clock := c.clock
+		c.mu.RUnlock()
+		if clock == nil {
+			clock = systemClock{}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(5 * time.Second):
+		}
+	}
+}
+
+// stopProviders cancels the Watch of every source. Caller must hold c.mu.
+func (c *Config) stopProviders() {
+	for _, p := range c.providers {
+		if p.cancel != nil {
+			p.cancel()
+			p.cancel = nil
+		}
+	}
+}
+
+// pollWatch calls notify every interval until ctx is done. Sources without
+// change notification use it as their Watch.
+func pollWatch(ctx context.Context, interval time.Duration, notify func()) error {
+	if interval <= 0 {
+		return nil
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			notify()
+		}
+	}
+}
+
+// propertyName maps a remote path such as database/host (after the source
+// prefix) to the property database.host
+func propertyName(path string, sep string) string {
+	parts := strings.Split(strings.Trim(path, sep), sep)
+	return JoinKey(parts...)
+}
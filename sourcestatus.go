@@ -0,0 +1,125 @@
+package config
+
+import (
+	"sort"
+	"time"
+)
+
+// SourceStatus describes the health of one config source
+type SourceStatus struct {
+	Name string // file name, OpenDir pattern, reader#N, URL, source name or env:PREFIX
+	Kind string // file, dir, reader, remote, source or env
+
+	// Version of the loaded content reported by the source, e.g. the commit
+	// of a GitSource
+	Version string
+
+	LastLoad    time.Time // last time the source was read successfully
+	LastError   error     // error of the last failed read, nil after a success
+	LastErrorAt time.Time
+
+	// Interval is how often the source is checked for changes, zero when it
+	// is only read on Reload
+	Interval time.Duration
+	// Stale is true when the current properties may not reflect the source
+	// because its last read failed, it was never read, or a polled source
+	// was not read for two intervals
+	Stale bool
+}
+
+type sourceState struct {
+	lastLoad    time.Time
+	lastError   error
+	lastErrorAt time.Time
+}
+
+// Return the status of every config source, in the order they are applied,
+// followed by the bound environment prefixes
+func (c *Config) SourceStatus() []SourceStatus {
+	if c.parent != nil {
+		return c.parent.SourceStatus()
+	}
+
+	sharedWatcher.mu.Lock()
+	watchInterval := sharedWatcher.interval
+	sharedWatcher.mu.Unlock()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.now()
+	var out []SourceStatus
+	for _, name := range c.sources {
+		st := SourceStatus{Name: name, Kind: c.sourceKind(name)}
+		switch {
+		case st.Kind == `remote`:
+			st.Interval = c.remotes[name].opts.Interval
+		case st.Kind == `source`:
+			src := c.providers[name].src
+			if p, ok := src.(polledSource); ok {
+				st.Interval = p.pollInterval()
+			}
+			if v, ok := src.(VersionedSource); ok {
+				st.Version = v.Version()
+			}
+		case c.watching && st.Kind != `reader`:
+			st.Interval = watchInterval
+		}
+		if s := c.sourceStates[name]; s != nil {
+			st.LastLoad, st.LastError, st.LastErrorAt = s.lastLoad, s.lastError, s.lastErrorAt
+		}
+		st.Stale = st.LastError != nil || st.LastLoad.IsZero() ||
+			(st.Kind == `remote` || st.Kind == `source`) && st.Interval > 0 && now.Sub(st.LastLoad) > 2*st.Interval
+		out = append(out, st)
+	}
+
+	prefixes := append([]string(nil), c.envPrefixes...)
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		// the environment is read on every Get
+		out = append(out, SourceStatus{Name: `env:` + prefix, Kind: `env`, LastLoad: now})
+	}
+	return out
+}
+
+func (c *Config) sourceKind(name string) string {
+	if _, ok := c.remotes[name]; ok {
+		return `remote`
+	}
+	if p, ok := c.providers[name]; ok && !isFileSource(p.src) {
+		return `source`
+	}
+	if _, ok := c.readers[name]; ok {
+		return `reader`
+	}
+	if _, ok := c.dirSources[name]; ok {
+		return `dir`
+	}
+	return `file`
+}
+
+// recordSources stores the result of a load. A failed source gets the error,
+// when failed is blank every source does.
+func (c *Config) recordSources(sources []string, failed string, e error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sourceStates == nil {
+		c.sourceStates = make(map[string]*sourceState)
+	}
+	now := c.now()
+	for _, name := range sources {
+		s := c.sourceStates[name]
+		if s == nil {
+			s = &sourceState{}
+			c.sourceStates[name] = s
+		}
+
+		switch {
+		case e == nil:
+			s.lastLoad, s.lastError = now, nil
+		case failed == `` || failed == name:
+			s.lastError, s.lastErrorAt = e, now
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLSource reads properties from the rows of a database table, one
+// property per row, so they can be edited with an admin UI backed by the
+// database. Any database/sql driver works.
+type SQLSource struct {
+	DB          *sql.DB
+	Table       string
+	KeyColumn   string // name by default
+	ValueColumn string // value by default
+	// Where filters the rows, e.g. "app = 'shop'", blank reads all rows
+	Where string
+
+	// Interval between reloads, zero reads the table only on Reload
+	Interval time.Duration
+}
+
+// Create source for table with the columns name and value, read again every
+// minute
+func NewSQLSource(db *sql.DB, table string) *SQLSource {
+	return &SQLSource{
+		DB:          db,
+		Table:       table,
+		KeyColumn:   `name`,
+		ValueColumn: `value`,
+		Interval:    time.Minute,
+	}
+}
+
+// Load reads every row of the table. NULL values are read as empty strings.
+func (s *SQLSource) Load(ctx context.Context) (map[string]string, error) {
+	for _, ident := range []string{s.Table, s.KeyColumn, s.ValueColumn} {
+		if !isSQLIdentifier(ident) {
+			return nil, fmt.Errorf(`sql: invalid identifier %q`, ident)
+		}
+	}
+
+	query := `SELECT ` + s.KeyColumn + `, ` + s.ValueColumn + ` FROM ` + s.Table
+	if s.Where != `` {
+		query += ` WHERE ` + s.Where
+	}
+
+	rows, e := s.DB.QueryContext(ctx, query)
+	if e != nil {
+		return nil, fmt.Errorf(`sql %s: %v`, s.Table, e)
+	}
+	defer rows.Close()
+
+	props := make(map[string]string)
+	for rows.Next() {
+		var key string
+		var val sql.NullString
+		if e := rows.Scan(&key, &val); e != nil {
+			return nil, fmt.Errorf(`sql %s: %v`, s.Table, e)
+		}
+		props[normalizeKey(key)] = val.String
+	}
+	if e := rows.Err(); e != nil {
+		return nil, fmt.Errorf(`sql %s: %v`, s.Table, e)
+	}
+	return props, nil
+}
+
+// Watch reloads the table every Interval
+func (s *SQLSource) Watch(ctx context.Context, notify func()) error {
+	return pollWatch(ctx, s.Interval, notify)
+}
+
+func (s *SQLSource) pollInterval() time.Duration {
+	return s.Interval
+}
+
+// isSQLIdentifier accepts plain and schema qualified names only, as the
+// identifiers are written into the query
+func isSQLIdentifier(s string) bool {
+	if s == `` {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if !(ch == '_' || ch == '.' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' && i > 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSMSource reads the parameters under a path from AWS Systems Manager
+// Parameter Store. Parameter Path + "database/host" becomes database.host.
+// SecureString parameters are decrypted and marked secret.
+type SSMSource struct {
+	Path        string
+	Region      string // AWS_REGION by default
+	Endpoint    string // e.g. a VPC endpoint, https://ssm.<region>.amazonaws.com by default
+	Credentials AWSCredentials
+	Client      *http.Client
+
+	// Interval between refreshes, zero reads the parameters only on Reload
+	Interval time.Duration
+
+	mu     sync.Mutex
+	secure map[string]bool
+}
+
+// Create source for the parameters under pathPrefix (e.g. /myapp/prod/),
+// refreshed every 5 minutes. Add it with Config.AddSource; SourceStatus
+// and GetStats report the last sync.
+func NewSSMSource(pathPrefix string) *SSMSource {
+	return &SSMSource{
+		Path:     pathPrefix,
+		Interval: 5 * time.Minute,
+	}
+}
+
+// Load reads every parameter under Path
+func (s *SSMSource) Load(ctx context.Context) (map[string]string, error) {
+	client, e := newAWSClient(`ssm`, s.Region, s.Endpoint, s.Credentials, s.Client)
+	if e != nil {
+		return nil, e
+	}
+
+	path := "/" + strings.Trim(s.Path, "/")
+	props := make(map[string]string)
+	secure := make(map[string]bool)
+	next := ``
+	for {
+		req := map[string]interface{}{
+			"Path":           path,
+			"Recursive":      true,
+			"WithDecryption": true,
+		}
+		if next != `` {
+			req["NextToken"] = next
+		}
+
+		var resp struct {
+			Parameters []struct {
+				Name  string
+				Type  string
+				Value string
+			}
+			NextToken string
+		}
+		if e := client.call(ctx, `AmazonSSM.GetParametersByPath`, req, &resp); e != nil {
+			return nil, e
+		}
+
+		for _, p := range resp.Parameters {
+			name := propertyName(strings.TrimPrefix(p.Name, path), "/")
+			if name == `` {
+				continue
+			}
+			props[name] = p.Value
+			if p.Type == `SecureString` {
+				secure[name] = true
+			}
+		}
+
+		next = resp.NextToken
+		if next == `` {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	s.secure = secure
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Watch refreshes the parameters every Interval
+func (s *SSMSource) Watch(ctx context.Context, notify func()) error {
+	return pollWatch(ctx, s.Interval, notify)
+}
+
+func (s *SSMSource) pollInterval() time.Duration {
+	return s.Interval
+}
+
+// IsSecret reports whether name is a SecureString parameter
+func (s *SSMSource) IsSecret(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.secure[name]
+}
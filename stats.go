@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"time"
+)
+
+// Stats describes the state of a Config
+type Stats struct {
+	Files       []string
+	Keys        int
+	Reloads     int
+	LastReload  time.Time
+	ReloadHooks []HookStats
+	Sources     []SourceStatus // last sync and error per source
+
+	// struct type metadata cache shared by all configs, see SetTypeCacheSize
+	TypeCacheHits   uint64
+	TypeCacheMisses uint64
+}
+
+// HookStats holds the timing of one function registered with OnReload
+type HookStats struct {
+	Name  string
+	Calls int
+	Last  time.Duration
+	Max   time.Duration
+	Total time.Duration
+}
+
+type reloadHook struct {
+	fn    func()
+	stats HookStats
+}
+
+// Return statistics about loaded files, properties and reloads
+func (c *Config) GetStats() Stats {
+	if c.parent != nil {
+		return c.parent.GetStats()
+	}
+
+	sources := c.SourceStatus()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s := Stats{
+		Files:      append([]string(nil), c.file...),
+		Keys:       len(c.storage),
+		Reloads:    c.reloads,
+		LastReload: c.lastReload,
+		Sources:    sources,
+	}
+	for _, h := range c.onReload {
+		s.ReloadHooks = append(s.ReloadHooks, h.stats)
+	}
+	s.TypeCacheHits, s.TypeCacheMisses = typeCache.stats()
+	return s
+}
+
+// Log a warning when a function registered with OnReload runs longer than d.
+// Zero disables the warning.
+func (c *Config) SetSlowHookThreshold(d time.Duration) {
+	if c.parent != nil {
+		c.parent.SetSlowHookThreshold(d)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.slowHook = d
+}
+
+func newReloadHook(fn func()) *reloadHook {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	return &reloadHook{fn: fn, stats: HookStats{Name: name}}
+}
+
+// runReloadHooks calls every OnReload function in order and records its timing
+func (c *Config) runReloadHooks() {
+	c.mu.Lock()
+	c.reloads++
+	c.lastReload = c.now()
+	hooks := append([]*reloadHook(nil), c.onReload...)
+	threshold := c.slowHook
+	clock := c.clock
+	c.mu.Unlock()
+
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	for _, h := range hooks {
+		start := clock.Now()
+		h.fn()
+		took := clock.Now().Sub(start)
+
+		c.mu.Lock()
+		h.stats.Calls++
+		h.stats.Last = took
+		h.stats.Total += took
+		if took > h.stats.Max {
+			h.stats.Max = took
+		}
+		c.mu.Unlock()
+
+		if threshold > 0 && took > threshold {
+			fmt.Println(`Slow reload hook:`, h.stats.Name, `took`, took)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+//go:build stress
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run with: go test -race -tags stress -run Stress -stress.duration 10s
+var stressDuration = flag.Duration(`stress.duration`, 5*time.Second, `how long TestStress runs`)
+
+type stressApp struct {
+	Name    string        `config:"name"`
+	Port    int           `config:"port"`
+	Debug   bool          `config:"debug"`
+	Ratio   float64       `config:"ratio"`
+	Timeout time.Duration `config:"timeout"`
+	DB      struct {
+		Host  string   `config:"host"`
+		Pool  int      `config:"pool"`
+		Hosts []string `config:"hosts"`
+	} `config:"database"`
+}
+
+// TestStress reads, decodes, reloads and sets properties of one Config from
+// many goroutines at once, so the race detector sees every path that
+// touches the shared storage.
+func TestStress(t *testing.T) {
+	quietStdout(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, `app.ini`)
+	writeStressFile(t, path, 0)
+
+	c := &Config{}
+	if e := c.Open(path); e != nil {
+		t.Fatal(e)
+	}
+	defer c.Close()
+
+	var reloads, sets, reads int64
+	var mu sync.Mutex
+	count := func(n *int64) {
+		mu.Lock()
+		*n++
+		mu.Unlock()
+	}
+
+	stop := make(chan struct{})
+	errs := make(chan error, 64)
+	var wg sync.WaitGroup
+	run := func(fn func(i int) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if e := fn(i); e != nil {
+					select {
+					case errs <- e:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	for g := 0; g < 4; g++ {
+		run(func(i int) error {
+			c.GetString(`app.name`)
+			c.GetInt(`app.port`)
+			c.GetBool(`app.debug`)
+			c.GetFloat64(`app.ratio`)
+			c.GetDuration(`app.timeout`)
+			c.GetStringSlice(`app.database.hosts`)
+			c.GetStringMap(`app.database`)
+			c.GetAll()
+			c.Sub(`app.database`).GetString(`host`)
+			count(&reads)
+			return nil
+		})
+	}
+	for g := 0; g < 2; g++ {
+		run(func(i int) error {
+			var app stressApp
+			if e := c.UnmarshalKey(`app`, &app); e != nil {
+				return e
+			}
+			var all map[string]interface{}
+			return c.MapToStructNested(&all)
+		})
+	}
+	run(func(i int) error {
+		writeStressFile(t, path, i)
+		if e := c.Reload(); e != nil {
+			return e
+		}
+		count(&reloads)
+		return nil
+	})
+	run(func(i int) error {
+		if e := c.Set(`app.database.pool`, strconv.Itoa(i)); e != nil {
+			return e
+		}
+		if e := c.Set(fmt.Sprintf(`runtime.key%d`, i%32), `v`); e != nil {
+			return e
+		}
+		count(&sets)
+		return nil
+	})
+
+	time.Sleep(*stressDuration)
+	close(stop)
+	wg.Wait()
+	close(errs)
+
+	for e := range errs {
+		t.Error(e)
+	}
+	t.Logf(`%d reads, %d reloads, %d sets`, reads, reloads, sets)
+}
+
+func writeStressFile(t *testing.T, path string, i int) {
+	data := fmt.Sprintf(`[app]
+name = stress %d
+port = %d
+debug = %t
+ratio = 0.%d
+timeout = %ds
+
+[app.database]
+host = db%d.local
+pool = %d
+hosts[] = a%d
+hosts[] = b%d
+`, i, 8000+i%1000, i%2 == 0, i%10, i%60+1, i%3, i%50, i, i)
+
+	// write next to path and rename, so Reload never reads a partial file
+	tmp := path + `.tmp`
+	if e := os.WriteFile(tmp, []byte(data), 0600); e != nil {
+		t.Error(e)
+		return
+	}
+	if e := os.Rename(tmp, path); e != nil {
+		t.Error(e)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrSubView is returned by the loading methods of a view returned by Sub
+var ErrSubView = errors.New(`config: not supported on a Sub view, call it on the parent`)
+
+// Return a view of the properties under prefix (e.g. "database") addressed
+// without it, so Sub("database").GetString("host") reads database.host.
+// The view reads the current properties of c and follows every Reload.
+// Every method reading or changing properties, such as Set, Update, Origin,
+// MarkSecret or OnReload, goes to c with the prefix added, and settings
+// such as SetInterpolate or SetFS are set on c. Parse settings such as
+// SetNumberFormat and SetCoercion are copied when Sub is called and stay
+// local to the view. Loading methods such as Open, AddSource or Close
+// return ErrSubView.
+func (c *Config) Sub(prefix string) *Config {
+	root, prefix := c, strings.Trim(normalizeKey(prefix), ".")
+	if c.parent != nil {
+		root, prefix = c.parent, c.subPrefix+prefix
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return &Config{
+		parent:          root,
+		subPrefix:       prefix + ".",
+		numbers:         c.numbers,
+		location:        c.location,
+		timeLayouts:     c.timeLayouts,
+		durationSeconds: c.durationSeconds,
+		sliceSep:        c.sliceSep,
+		coerce:          c.coerce,
+		strict:          c.strict,
+	}
+}
+
+// root returns the Config holding the properties read by c and the prefix
+// of the names of c in it, blank when c is not a view
+func (c *Config) root() (*Config, string) {
+	if c.parent != nil {
+		return c.parent, c.subPrefix
+	}
+	return c, ``
+}
+
+// subChanges returns the changes under the prefix of view c with the prefix
+// removed
+func (c *Config) subChanges(changes []Change) []Change {
+	out := make([]Change, 0, len(changes))
+	for _, ch := range changes {
+		if strings.HasPrefix(ch.Key, c.subPrefix) {
+			ch.Key = ch.Key[len(c.subPrefix):]
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// subKeys returns the keys of m prefixed with the prefix of view c
+func (c *Config) subKeys(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[c.subPrefix+normalizeKey(k)] = v
+	}
+	return out
+}
+
+// subNames returns names prefixed with the prefix of view c
+func (c *Config) subNames(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = c.subPrefix + normalizeKey(name)
+	}
+	return out
+}
+
+// subTxn is the Txn of an Update made through a view
+type subTxn struct {
+	tx     Txn
+	prefix string
+}
+
+func (tx *subTxn) Get(name string) (string, bool) {
+	return tx.tx.Get(tx.prefix + normalizeKey(name))
+}
+
+func (tx *subTxn) Set(name string, value string) {
+	tx.tx.Set(tx.prefix+normalizeKey(name), value)
+}
+
+func (tx *subTxn) Delete(name string) {
+	tx.tx.Delete(tx.prefix + normalizeKey(name))
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func newSubTestConfig(t *testing.T) *Config {
+	t.Helper()
+
+	c := &Config{}
+	if e := c.LoadString("[db]\nhost = localhost\nport = 5432\n", `ini`); e != nil {
+		t.Fatal(e)
+	}
+	return c
+}
+
+func TestSubUpdate(t *testing.T) {
+	c := newSubTestConfig(t)
+	db := c.Sub(`db`)
+
+	e := db.Update(func(tx Txn) error {
+		if v, ok := tx.Get(`host`); !ok || v != `localhost` {
+			t.Errorf(`tx.Get(host) = %q, %v`, v, ok)
+		}
+		tx.Set(`host`, `db.internal`)
+		tx.Delete(`port`)
+		return nil
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if got := c.GetString(`db.host`); got != `db.internal` {
+		t.Errorf(`parent db.host = %q, want db.internal`, got)
+	}
+	if _, ok := c.GetRaw(`db.port`); ok {
+		t.Error(`parent db.port still set after Delete through the view`)
+	}
+	if got := db.GetString(`host`); got != `db.internal` {
+		t.Errorf(`view host = %q, want db.internal`, got)
+	}
+	if got := db.Origin(`host`); got != `override` {
+		t.Errorf(`view Origin(host) = %q, want override`, got)
+	}
+	if got := c.GetOverrides(); got[`db.host`] != `db.internal` {
+		t.Errorf(`parent overrides = %v`, got)
+	}
+	if got := db.GetOverrides(); got[`host`] != `db.internal` || len(got) != 1 {
+		t.Errorf(`view overrides = %v`, got)
+	}
+}
+
+func TestSubForwardsToParent(t *testing.T) {
+	c := newSubTestConfig(t)
+	db := c.Sub(`db`)
+
+	if got := db.Origin(`port`); got != c.Origin(`db.port`) || got == `` {
+		t.Errorf(`view Origin(port) = %q, parent %q`, got, c.Origin(`db.port`))
+	}
+
+	db.MarkSecret(`host`)
+	if !c.IsSecret(`db.host`) || !db.IsSecret(`host`) {
+		t.Error(`MarkSecret on the view did not mark db.host on the parent`)
+	}
+	if got := db.DisplayValue(`host`); got != c.DisplayValue(`db.host`) || got == `localhost` {
+		t.Errorf(`view DisplayValue(host) = %q`, got)
+	}
+
+	if e := db.DeclareType(`port`, KindString); e != nil {
+		t.Fatal(e)
+	}
+	if got := c.GetAny(`db.port`); got != `5432` {
+		t.Errorf(`parent GetAny(db.port) = %#v, want the declared string`, got)
+	}
+	if got := db.GetAllAsInterface()[`port`]; got != `5432` {
+		t.Errorf(`view GetAllAsInterface port = %#v`, got)
+	}
+	for _, g := range db.ExplainTypes() {
+		if g.Key == `port` && !g.Declared {
+			t.Errorf(`view ExplainTypes port not declared: %+v`, g)
+		}
+	}
+
+	if s := db.Summary(); s.Keys != 2 || s.Secrets != 1 {
+		t.Errorf(`view Summary = %+v`, s)
+	}
+
+	if e := db.Set(`user`, `app`); e != nil {
+		t.Fatal(e)
+	}
+	if got := c.GetString(`db.user`); got != `app` {
+		t.Errorf(`parent db.user = %q, want app`, got)
+	}
+}
+
+func TestSubLoadingMethods(t *testing.T) {
+	c := newSubTestConfig(t)
+	db := c.Sub(`db`)
+
+	for name, e := range map[string]error{
+		`Open`:       db.Open(`app.ini`),
+		`LoadString`: db.LoadString(`a = 1`, `ini`),
+		`Close`:      db.Close(),
+	} {
+		if !errors.Is(e, ErrSubView) {
+			t.Errorf(`%s on a view returned %v, want ErrSubView`, name, e)
+		}
+	}
+	if c.State() == StateClosed {
+		t.Error(`Close on the view closed the parent`)
+	}
+}
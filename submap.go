@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Return the properties under prefix (e.g. "database") with the prefix
+// removed, so database.host is returned as host. Empty when no property is
+// under prefix.
+func (c *Config) GetStringMap(prefix string) map[string]string {
+	prefix = strings.TrimSuffix(normalizeKey(prefix), ".") + "."
+
+	out := make(map[string]string)
+	for k, v := range c.GetAll() {
+		if strings.HasPrefix(k, prefix) {
+			out[k[len(prefix):]] = v
+		} else if strings.HasPrefix(k, "."+prefix) {
+			out[k[len(prefix)+1:]] = v
+		}
+	}
+	return out
+}
+
+// Return the properties under prefix as nested maps and arrays, like
+// GetAllNested does for all properties
+func (c *Config) GetStringMapNested(prefix string) map[string]interface{} {
+	return Unflatten(c.GetStringMap(prefix))
+}
+
+// Return the properties under prefix as a JSON document, for config blocks
+// decoded by their consumer. Values are typed like GetAllAsInterface and a
+// prefix with only indexed keys (name.0, name.1, ...) becomes an array.
+// Returns ErrKeyNotFound if no property is under prefix.
+func (c *Config) GetRawJSON(prefix string) (json.RawMessage, error) {
+	if c.parent != nil {
+		return c.parent.GetRawJSON(c.subPrefix + strings.Trim(normalizeKey(prefix), "."))
+	}
+
+	flat := c.GetStringMap(prefix)
+	if len(flat) == 0 {
+		return nil, c.notFound(prefix)
+	}
+
+	full := strings.TrimSuffix(normalizeKey(prefix), ".") + "."
+	typed := make(map[string]interface{}, len(flat))
+	c.mu.RLock()
+	for k, v := range flat {
+		typed[k], _ = c.typedValue(full+k, v)
+	}
+	c.mu.RUnlock()
+
+	data, e := json.Marshal(arrayify(unflattenValues(typed)))
+	if e != nil {
+		return nil, c.scrub(e)
+	}
+	return data, nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Summary describes the loaded config for startup logs and support bundles
+type Summary struct {
+	Files    []string
+	Keys     int
+	Prefixes map[string]int // number of properties per top level key part
+
+	// EmptyFiles lists config files without any property, see SetRequireNonEmpty
+	EmptyFiles []string
+
+	EnvOverrides int // properties currently read from environment variables
+	Overrides    int // runtime overrides made with Set
+	Secrets      int // properties marked secret, including _file secrets
+
+	// Hash is the SHA-256 of all properties, equal for equal configs
+	Hash string
+}
+
+// Return a summary of the loaded config. Its String form fits a single log line.
+func (c *Config) Summary() Summary {
+	all := c.GetAll()
+	root, prefix := c.root()
+
+	root.mu.RLock()
+	env := root.envValues(root.storage)
+	s := Summary{
+		Files:        uniqueStrings(root.file),
+		EmptyFiles:   uniqueStrings(root.emptyFiles),
+		Keys:         len(all),
+		Prefixes:     make(map[string]int),
+		EnvOverrides: countPrefixed(env, prefix),
+		Overrides:    countPrefixed(root.overrides, prefix),
+		Secrets:      countPrefixed(root.secrets, prefix),
+	}
+	root.mu.RUnlock()
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+		s.Prefixes[SplitKey(strings.TrimPrefix(k, "."))[0]]++
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%q=%q\n", k, all[k])
+	}
+	s.Hash = hex.EncodeToString(h.Sum(nil))
+	return s
+}
+
+func (s Summary) String() string {
+	prefixes := make([]string, 0, len(s.Prefixes))
+	for p, n := range s.Prefixes {
+		prefixes = append(prefixes, fmt.Sprintf(`%s=%d`, p, n))
+	}
+	sort.Strings(prefixes)
+
+	hash := s.Hash
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	empty := ``
+	if len(s.EmptyFiles) > 0 {
+		empty = fmt.Sprintf(` (%d empty)`, len(s.EmptyFiles))
+	}
+	return fmt.Sprintf(`config %s: %d files%s, %d keys (%s), %d env, %d overrides, %d secrets`,
+		hash, len(s.Files), empty, s.Keys, strings.Join(prefixes, ` `), s.EnvOverrides, s.Overrides, s.Secrets)
+}
+
+// countPrefixed returns the number of keys of m starting with prefix
+func countPrefixed[V any](m map[string]V, prefix string) int {
+	n := 0
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// uniqueStrings returns s without repeated values, keeping the first
+func uniqueStrings(s []string) []string {
+	out := make([]string, 0, len(s))
+	for _, v := range s {
+		if !contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// layouts of timestamps without zone, read in the default location
+var naiveTimeLayouts = []string{
+	`2006-01-02T15:04:05`,
+	`2006-01-02 15:04:05`,
+	`2006-01-02`,
+}
+
+// Read property holding an IANA time zone name such as "Asia/Jakarta" or
+// "UTC". Returns ErrKeyNotFound if property is not exists.
+func (c *Config) GetLocation(name string) (*time.Location, error) {
+	var loc *time.Location
+	e := c.parseKind(name, KindString, func(s string) (e error) {
+		if s == `` {
+			return errors.New(`empty time zone name`)
+		}
+		loc, e = time.LoadLocation(s)
+		return e
+	})
+	return loc, e
+}
+
+// Set location used by GetTime for timestamps written without zone, e.g.
+// "2024-05-01 08:00:00". Defaults to UTC, nil restores the default.
+func (c *Config) SetDefaultLocation(loc *time.Location) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.location = loc
+}
+
+// Accept timestamps in layouts (e.g. "02/01/2006 15:04") in GetTime, tried
+// in order after RFC3339 and the built-in layouts. Layouts without zone are
+// read in the location set with SetDefaultLocation.
+func (c *Config) AddTimeLayouts(layouts ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.timeLayouts = append(append([]string(nil), c.timeLayouts...), layouts...)
+}
+
+// Read time property. If property is not exists or invalid will return the
+// zero time
+func (c *Config) GetTime(name string) time.Time {
+	return c.GetTimeOr(name, time.Time{})
+}
+
+// Read time property or return defValue if property is not exists or invalid
+func (c *Config) GetTimeOr(name string, defValue time.Time) time.Time {
+	v, e := c.GetTimeE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read time property, returns an error if property is not exists or
+// invalid. RFC3339 values keep their offset, values without zone
+// (2006-01-02 15:04:05, 2006-01-02T15:04:05 or 2006-01-02) are read in the
+// location set with SetDefaultLocation. Layouts added with AddTimeLayouts
+// are tried last.
+func (c *Config) GetTimeE(name string) (time.Time, error) {
+	c.mu.RLock()
+	loc := c.location
+	layouts := c.timeLayouts
+	c.mu.RUnlock()
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var t time.Time
+	e := c.parseKind(name, KindTime, func(s string) (e error) {
+		t, e = parseTime(s, loc, layouts...)
+		return e
+	})
+	return t, e
+}
+
+// parseTime reads s as RFC3339, then in the built-in layouts and layouts
+func parseTime(s string, loc *time.Location, layouts ...string) (time.Time, error) {
+	t, e := time.Parse(time.RFC3339Nano, s)
+	if e == nil {
+		return t, nil
+	}
+	for _, layout := range naiveTimeLayouts {
+		if t, e2 := time.ParseInLocation(layout, s, loc); e2 == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range layouts {
+		if t, e2 := time.ParseInLocation(layout, s, loc); e2 == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, e
+}
+
+// Read bare integer durations such as "30" as seconds in GetDuration. By
+// default a unit is required, as time.ParseDuration does.
+func (c *Config) SetDurationSeconds(enable bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.durationSeconds = enable
+}
+
+// Read duration property such as "30s", "5m" or "1h30m". If property is not
+// exists or invalid will return 0
+func (c *Config) GetDuration(name string) time.Duration {
+	return c.GetDurationOr(name, 0)
+}
+
+// Read duration property or return defValue if property is not exists or
+// invalid
+func (c *Config) GetDurationOr(name string, defValue time.Duration) time.Duration {
+	v, e := c.GetDurationE(name)
+	if e != nil {
+		c.reportInvalid(e)
+		return defValue
+	}
+	return v
+}
+
+// Read duration property, returns an error if property is not exists or
+// invalid. Bare integers are seconds when SetDurationSeconds is enabled.
+func (c *Config) GetDurationE(name string) (time.Duration, error) {
+	c.mu.RLock()
+	seconds := c.durationSeconds
+	c.mu.RUnlock()
+
+	var d time.Duration
+	e := c.parseKind(name, KindDuration, func(s string) (e error) {
+		d, e = parseDuration(s, seconds)
+		return e
+	})
+	return d, e
+}
+
+func parseDuration(s string, seconds bool) (time.Duration, error) {
+	if seconds {
+		if n, e := strconv.ParseInt(s, 10, 64); e == nil {
+			return time.Duration(n) * time.Second, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,615 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Read TOML config file. Tables become key prefixes and arrays of tables
+// become indexed keys, e.g. [[servers]] host = "a" is stored as servers.0.host.
+// Like in INI files, keys outside any table are stored with a leading dot.
+func (f *File) ReadTOML(c *Config) error {
+	data, e := f.readAll(c)
+	if e != nil {
+		return e
+	}
+
+	fmt.Println(`Read config:`, f.filename)
+	c.file = append(c.file, f.filename)
+
+	root, e := parseTOML(string(data))
+	if e != nil {
+		return fmt.Errorf(`%s: %v`, f.filename, e)
+	}
+
+	for k, v := range root {
+		key := EscapeKey(k)
+		if !isTOMLTable(v) {
+			key = "." + key // property declared outside any table
+		}
+		flatten(key, v, c.storage)
+	}
+	return nil
+}
+
+// isTOMLTable reports whether v is a table or an array of tables
+func isTOMLTable(v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		for _, item := range val {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return false
+			}
+		}
+		return len(val) > 0
+	}
+	return false
+}
+
+type tomlParser struct {
+	src string
+	pos int
+}
+
+func parseTOML(src string) (map[string]interface{}, error) {
+	p := &tomlParser{src: src}
+	root := make(map[string]interface{})
+	cur := root
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+
+		var e error
+		if p.peek() == '[' {
+			cur, e = p.parseTableHeader(root)
+		} else {
+			e = p.parseKeyValue(cur)
+		}
+		if e != nil {
+			return nil, e
+		}
+
+		if e := p.endOfLine(); e != nil {
+			return nil, e
+		}
+	}
+}
+
+func (p *tomlParser) parseTableHeader(root map[string]interface{}) (map[string]interface{}, error) {
+	isArray := strings.HasPrefix(p.src[p.pos:], `[[`)
+	if isArray {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+
+	key, e := p.parseKey()
+	if e != nil {
+		return nil, e
+	}
+
+	closing := `]`
+	if isArray {
+		closing = `]]`
+	}
+	if !strings.HasPrefix(p.src[p.pos:], closing) {
+		return nil, p.errorf(`expected %q`, closing)
+	}
+	p.pos += len(closing)
+
+	parent, e := p.table(root, key[:len(key)-1])
+	if e != nil {
+		return nil, e
+	}
+	last := key[len(key)-1]
+
+	if isArray {
+		arr, ok := parent[last].([]interface{})
+		if _, exists := parent[last]; exists && !ok {
+			return nil, p.errorf(`key %q is not an array of tables`, strings.Join(key, "."))
+		}
+		t := make(map[string]interface{})
+		parent[last] = append(arr, t)
+		return t, nil
+	}
+	return p.table(parent, []string{last})
+}
+
+// table walks (and creates) nested tables, entering the last element of
+// arrays of tables
+func (p *tomlParser) table(from map[string]interface{}, key []string) (map[string]interface{}, error) {
+	cur := from
+	for _, part := range key {
+		switch next := cur[part].(type) {
+		case nil:
+			t := make(map[string]interface{})
+			cur[part] = t
+			cur = t
+		case map[string]interface{}:
+			cur = next
+		case []interface{}:
+			if len(next) == 0 {
+				return nil, p.errorf(`key %q is not a table`, part)
+			}
+			t, ok := next[len(next)-1].(map[string]interface{})
+			if !ok {
+				return nil, p.errorf(`key %q is not a table`, part)
+			}
+			cur = t
+		default:
+			return nil, p.errorf(`key %q is not a table`, part)
+		}
+	}
+	return cur, nil
+}
+
+func (p *tomlParser) parseKeyValue(into map[string]interface{}) error {
+	key, e := p.parseKey()
+	if e != nil {
+		return e
+	}
+	if p.peek() != '=' {
+		return p.errorf(`expected "=" after key %q`, strings.Join(key, "."))
+	}
+	p.pos++
+	p.skipSpace()
+
+	val, e := p.parseValue()
+	if e != nil {
+		return e
+	}
+
+	t, e := p.table(into, key[:len(key)-1])
+	if e != nil {
+		return e
+	}
+	last := key[len(key)-1]
+	if _, exists := t[last]; exists {
+		return p.errorf(`duplicate key %q`, strings.Join(key, "."))
+	}
+	t[last] = val
+	return nil
+}
+
+func (p *tomlParser) parseKey() ([]string, error) {
+	var key []string
+	for {
+		p.skipSpace()
+		var part string
+		var e error
+		switch p.peek() {
+		case '"':
+			part, e = p.parseBasicString()
+		case '\'':
+			part, e = p.parseLiteralString()
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf(`invalid key`)
+			}
+			part = p.src[start:p.pos]
+		}
+		if e != nil {
+			return nil, e
+		}
+		key = append(key, part)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return key, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	rest := p.src[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		return p.parseMultilineString(`"""`)
+	case strings.HasPrefix(rest, `'''`):
+		return p.parseMultilineString(`'''`)
+	case strings.HasPrefix(rest, `"`):
+		return p.parseBasicString()
+	case strings.HasPrefix(rest, `'`):
+		return p.parseLiteralString()
+	case strings.HasPrefix(rest, `[`):
+		return p.parseArray()
+	case strings.HasPrefix(rest, `{`):
+		return p.parseInlineTable()
+	}
+	return p.parseScalar()
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+	p.pos++
+	arr := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+
+		val, e := p.parseValue()
+		if e != nil {
+			return nil, e
+		}
+		arr = append(arr, val)
+
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf(`expected "," or "]" in array`)
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	p.pos++
+	t := make(map[string]interface{})
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return t, nil
+	}
+
+	for {
+		if e := p.parseKeyValue(t); e != nil {
+			return nil, e
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return t, nil
+		default:
+			return nil, p.errorf(`expected "," or "}" in inline table`)
+		}
+	}
+}
+
+func (p *tomlParser) parseScalar() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	// local date-time written with a space: 1979-05-27 07:32:00
+	if p.pos-start == 10 && strings.Count(p.src[start:p.pos], "-") == 2 &&
+		p.pos+1 < len(p.src) && p.src[p.pos] == ' ' && isDigit(p.src[p.pos+1]) {
+		p.pos++
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+			p.pos++
+		}
+	}
+
+	tok := p.src[start:p.pos]
+	switch {
+	case tok == ``:
+		return nil, p.errorf(`missing value`)
+	case tok == `true` || tok == `false`:
+		return tok == `true`, nil
+	case strings.TrimLeft(tok, "+-") == `inf` || strings.TrimLeft(tok, "+-") == `nan`:
+		return tok, nil
+	case len(tok) >= 5 && (tok[4] == '-' && allDigits(tok[:4]) || tok[2] == ':' && allDigits(tok[:2])):
+		return tok, nil
+	}
+
+	if i, ok := parseTOMLInt(tok); ok {
+		return i, nil
+	}
+	if f, ok := parseTOMLFloat(tok); ok {
+		return f, nil
+	}
+	return nil, p.errorf(`invalid value %q`, tok)
+}
+
+// parseTOMLInt parses a decimal integer without leading zeros or a 0x, 0o
+// or 0b integer, with _ allowed between digits
+func parseTOMLInt(tok string) (int64, bool) {
+	base, digits := 10, tok
+	if len(tok) > 2 && tok[0] == '0' {
+		switch tok[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base != 10 {
+			digits = tok[2:]
+		}
+	}
+
+	if base == 10 {
+		unsigned := strings.TrimLeft(tok, "+-")
+		if len(tok)-len(unsigned) > 1 || (len(unsigned) > 1 && unsigned[0] == '0') {
+			return 0, false
+		}
+		digits = unsigned
+	}
+	if !tomlDigits(digits, base) {
+		return 0, false
+	}
+
+	if base == 10 {
+		digits = tok // ParseInt takes the sign
+	}
+	i, e := strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
+	return i, e == nil
+}
+
+// parseTOMLFloat parses a float with a fraction and/or an exponent. The
+// integer part has no leading zeros and _ is allowed between digits.
+func parseTOMLFloat(tok string) (float64, bool) {
+	mantissa, exp := tok, ``
+	if i := strings.IndexAny(tok, "eE"); i >= 0 {
+		mantissa, exp = tok[:i], tok[i+1:]
+		if !tomlDigits(strings.TrimLeft(exp, "+-"), 10) || len(exp)-len(strings.TrimLeft(exp, "+-")) > 1 {
+			return 0, false
+		}
+	}
+
+	intPart, frac, hasFrac := strings.Cut(mantissa, ".")
+	if exp == `` && !hasFrac {
+		return 0, false
+	}
+	if hasFrac && !tomlDigits(frac, 10) {
+		return 0, false
+	}
+	unsigned := strings.TrimLeft(intPart, "+-")
+	if len(intPart)-len(unsigned) > 1 || !tomlDigits(unsigned, 10) || (len(unsigned) > 1 && unsigned[0] == '0') {
+		return 0, false
+	}
+
+	f, e := strconv.ParseFloat(strings.ReplaceAll(tok, "_", ""), 64)
+	return f, e == nil
+}
+
+// tomlDigits reports whether s is a non-empty run of digits of base with
+// every _ between two digits
+func tomlDigits(s string, base int) bool {
+	if s == `` || s[0] == '_' || s[len(s)-1] == '_' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' {
+			if s[i+1] == '_' {
+				return false
+			}
+			continue
+		}
+		if digitValue(s[i]) >= base {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return ``, p.errorf(`unterminated string`)
+		}
+		ch := p.peek()
+		switch ch {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if e := p.parseEscape(&b); e != nil {
+				return ``, e
+			}
+		default:
+			b.WriteByte(ch)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return ``, p.errorf(`unterminated string`)
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.pos += len(delim)
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if strings.HasPrefix(p.src[p.pos:], "\n") {
+		p.pos++
+	}
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return ``, p.errorf(`unterminated string`)
+		}
+		if strings.HasPrefix(p.src[p.pos:], delim) {
+			p.pos += len(delim)
+			// up to two quotes right before the closing delimiter belong to the string
+			for i := 0; i < 2 && !p.eof() && p.peek() == delim[0]; i++ {
+				b.WriteByte(delim[0])
+				p.pos++
+			}
+			return b.String(), nil
+		}
+
+		ch := p.peek()
+		if ch == '\\' && delim == `"""` {
+			if line := strings.TrimLeft(p.src[p.pos+1:], " \t\r"); strings.HasPrefix(line, "\n") {
+				// line ending backslash trims the newline and leading whitespace
+				p.pos = len(p.src) - len(strings.TrimLeft(line, " \t\r\n"))
+				continue
+			}
+			if e := p.parseEscape(&b); e != nil {
+				return ``, e
+			}
+			continue
+		}
+		b.WriteByte(ch)
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	if p.pos+1 >= len(p.src) {
+		return p.errorf(`invalid escape`)
+	}
+	ch := p.src[p.pos+1]
+	p.pos += 2
+
+	switch ch {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"', '\\':
+		b.WriteByte(ch)
+	case 'u', 'U':
+		n := 4
+		if ch == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return p.errorf(`invalid unicode escape`)
+		}
+		r, e := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if e != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf(`invalid unicode escape`)
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return p.errorf(`invalid escape "\\%c"`, ch)
+	}
+	return nil
+}
+
+// endOfLine accepts trailing whitespace and a comment before the newline
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		p.skipComment()
+	}
+	if p.eof() {
+		return nil
+	}
+	if p.peek() == '\r' {
+		p.pos++
+	}
+	if p.peek() != '\n' {
+		return p.errorf(`expected end of line`)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	end := p.pos
+	if end > len(p.src) {
+		end = len(p.src)
+	}
+	line := strings.Count(p.src[:end], "\n") + 1
+	return fmt.Errorf(`line %d: %s`, line, fmt.Sprintf(format, args...))
+}
+
+func isBareKeyChar(ch byte) bool {
+	return ch == '_' || ch == '-' || isDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// digitValue returns the value of the hexadecimal digit ch, 16 for any
+// other byte
+func digitValue(ch byte) int {
+	switch {
+	case isDigit(ch):
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'f':
+		return int(ch-'a') + 10
+	case ch >= 'A' && ch <= 'F':
+		return int(ch-'A') + 10
+	}
+	return 16
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]string
+	}{
+		{`basic string`, `s = "a\tb \"q\" \u00e9"`, map[string]string{`s`: "a\tb \"q\" é"}},
+		{`literal string`, `s = 'C:\path\n'`, map[string]string{`s`: `C:\path\n`}},
+		{`multiline string`, "s = \"\"\"\none \\\n   two\"\"\"", map[string]string{`s`: `one two`}},
+		{`multiline literal`, "s = '''\nraw \\n\n'''", map[string]string{`s`: "raw \\n\n"}},
+		{`decimal`, `a = 42` + "\n" + `b = -17` + "\n" + `c = +0` + "\n" + `d = 1_000`, map[string]string{`a`: `42`, `b`: `-17`, `c`: `0`, `d`: `1000`}},
+		{`prefixed`, "a = 0xDEAD_beef\nb = 0o755\nc = 0b1101", map[string]string{`a`: `3735928559`, `b`: `493`, `c`: `13`}},
+		{`float`, "a = 3.14\nb = -0.5\nc = 5e+2\nd = 6.25e-2\ne = 1_0.5", map[string]string{`a`: `3.14`, `b`: `-0.5`, `c`: `500`, `d`: `0.0625`, `e`: `10.5`}},
+		{`special float`, "a = inf\nb = -inf\nc = nan", map[string]string{`a`: `inf`, `b`: `-inf`, `c`: `nan`}},
+		{`bool`, "a = true\nb = false", map[string]string{`a`: `true`, `b`: `false`}},
+		{`dates`, "a = 1979-05-27T07:32:00Z\nb = 1979-05-27 07:32:00\nc = 1979-05-27\nd = 07:32:00", map[string]string{`a`: `1979-05-27T07:32:00Z`, `b`: `1979-05-27 07:32:00`, `c`: `1979-05-27`, `d`: `07:32:00`}},
+		{`array`, "a = [ 1, 2, ]\nb = [\n  \"x\", # comment\n  [true],\n]", map[string]string{`a.0`: `1`, `a.1`: `2`, `b.0`: `x`, `b.1.0`: `true`}},
+		{`inline table`, `t = { host = "db", port = 5432, opts = { tls = true } }`, map[string]string{`t.host`: `db`, `t.port`: `5432`, `t.opts.tls`: `true`}},
+		{`tables`, "[db]\nhost = \"db\"\n[db.replica]\nhost = \"r\"\n[\"a.b\"]\nc = 1", map[string]string{`db.host`: `db`, `db.replica.host`: `r`, `a\.b.c`: `1`}},
+		{`dotted key`, `site."google.com" = true`, map[string]string{`site.google\.com`: `true`}},
+		{`array of tables`, "[[servers]]\nhost = \"a\"\n[[servers]]\nhost = \"b\"\n[servers.tls]\ncert = \"c\"", map[string]string{`servers.0.host`: `a`, `servers.1.host`: `b`, `servers.1.tls.cert`: `c`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, e := parseTOML(tt.src)
+			if e != nil {
+				t.Fatal(e)
+			}
+			if got := Flatten(root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(`got %v, want %v`, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`a = 010`, `invalid value "010"`},
+		{`a = -01`, `invalid value "-01"`},
+		{`a = 00.5`, `invalid value "00.5"`},
+		{`a = 1__000`, `invalid value "1__000"`},
+		{`a = _1`, `invalid value "_1"`},
+		{`a = 1_`, `invalid value "1_"`},
+		{`a = 0X1F`, `invalid value "0X1F"`},
+		{`a = -0x1`, `invalid value "-0x1"`},
+		{`a = 0o8`, `invalid value "0o8"`},
+		{`a = 0b102`, `invalid value "0b102"`},
+		{`a = 1.`, `invalid value "1."`},
+		{`a = .5`, `invalid value ".5"`},
+		{`a = 1e`, `invalid value "1e"`},
+		{`a = 0x1p-2`, `invalid value "0x1p-2"`},
+		{`a = "open`, `unterminated string`},
+		{`a = "\q"`, `invalid escape`},
+		{`a = [1 2]`, `expected "," or "]" in array`},
+		{`a = { b = 1 c = 2 }`, `expected "," or "}" in inline table`},
+		{"a = 1\na = 2", `line 2: duplicate key "a"`},
+		{"a = 1\n[a]", `key "a" is not a table`},
+		{"[t]\n[[t]]", `key "t" is not an array of tables`},
+		{`a = 1 b = 2`, `expected end of line`},
+		{`[t`, `expected "]"`},
+		{`a =`, `missing value`},
+	}
+
+	for _, tt := range tests {
+		_, e := parseTOML(tt.src)
+		if e == nil || !strings.Contains(e.Error(), tt.want) {
+			t.Errorf(`parseTOML(%q) = %v, want %q`, tt.src, e, tt.want)
+		}
+	}
+}
+
+func TestReadTOMLKeysLikeINI(t *testing.T) {
+	quietStdout(t)
+
+	ini := &Config{}
+	if e := ini.LoadString("name = demo\nports[] = 80\nports[] = 443\n[db]\nhost = db\n", `ini`); e != nil {
+		t.Fatal(e)
+	}
+	toml := &Config{}
+	if e := toml.LoadString("name = \"demo\"\nports = [80, 443]\n[db]\nhost = \"db\"\n", `toml`); e != nil {
+		t.Fatal(e)
+	}
+
+	if got, want := toml.GetAll(), ini.GetAll(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`TOML keys %v, INI keys %v`, got, want)
+	}
+	if got := toml.GetString(`.name`); got != `demo` {
+		t.Errorf(`GetString(.name) = %q`, got)
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import "sort"
+
+// Txn collects the changes of an Update
+type Txn interface {
+	// Get returns the value of property name with the changes made so far
+	Get(name string) (string, bool)
+	// Set property name to value, like Config.Set
+	Set(name string, value string)
+	// Delete property name until it is Set again
+	Delete(name string)
+}
+
+type txn struct {
+	c       *Config
+	changes map[string]*string // nil value deletes
+}
+
+func (tx *txn) Get(name string) (string, bool) {
+	name = normalizeKey(name)
+	if v, ok := tx.changes[name]; ok {
+		if v == nil {
+			return ``, false
+		}
+		return *v, true
+	}
+	return tx.c.lookupRaw(name)
+}
+
+func (tx *txn) Set(name string, value string) {
+	tx.changes[normalizeKey(name)] = &value
+}
+
+func (tx *txn) Delete(name string) {
+	tx.changes[normalizeKey(name)] = nil
+}
+
+// Change several properties at once. The changes made by fn are checked
+// against the declared types, required properties and validators, then
+// applied together with a single change event, or not at all when fn or a
+// check fails. Readers never see part of them. Like Set the values are
+// pushed to the remote sink first, in one transaction when the sink is a
+// TxnSink such as EtcdSink and ConsulSink. Other sinks are written key by
+// key and are not atomic: after a failed write the keys already written
+// are put back to their previous value as far as possible. The values are
+// persisted in the overrides file; deletions are not persisted and do not
+// hide environment variables.
+func (c *Config) Update(fn func(tx Txn) error) error {
+	if c.parent != nil {
+		return c.parent.Update(func(tx Txn) error {
+			return fn(&subTxn{tx: tx, prefix: c.subPrefix})
+		})
+	}
+
+	tx := &txn{c: c, changes: make(map[string]*string)}
+	if e := fn(tx); e != nil {
+		return e
+	}
+	if len(tx.changes) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tx.changes))
+	for k := range tx.changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if e := c.validateUpdate(tx.changes); e != nil {
+		return e
+	}
+	var pushed []string
+	values := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v := tx.changes[k]; v != nil {
+			pushed = append(pushed, k)
+			values[k] = *v
+		}
+	}
+	if e := c.pushRemoteAll(pushed, values); e != nil {
+		return e
+	}
+
+	c.mu.Lock()
+	storage := copyStorage(c.storage)
+	overrides := copyStorage(c.overrides)
+	deleted := make(map[string]bool, len(c.deleted))
+	for k := range c.deleted {
+		deleted[k] = true
+	}
+	for _, k := range keys {
+		if v := tx.changes[k]; v != nil {
+			storage[k] = *v
+			overrides[k] = *v
+			delete(deleted, k)
+		} else {
+			delete(storage, k)
+			delete(overrides, k)
+			deleted[k] = true
+		}
+	}
+	changes := diffStorage(c.storage, storage)
+	c.storage = storage
+	c.overrides = overrides
+	c.deleted = deleted
+	path := c.overridesPath
+	fsys := c.filesystem()
+	persisted := copyStorage(overrides)
+	c.rewriteOverrides(persisted)
+	c.mu.Unlock()
+
+	c.publish(changes)
+
+	if path == `` {
+		return nil
+	}
+	return writeOverrides(fsys, path, persisted)
+}
+
+// validateUpdate checks the properties with changes applied. Returns a
+// *ValidationError.
+func (c *Config) validateUpdate(changes map[string]*string) error {
+	c.mu.RLock()
+	storage := copyStorage(c.storage)
+	for k, v := range changes {
+		if v != nil {
+			storage[k] = *v
+		} else {
+			delete(storage, k)
+		}
+	}
+	view := c.view(storage)
+	required := append([]string(nil), c.required...)
+	validators := append([]func(*Config) error(nil), c.validators...)
+	for _, ns := range c.namespaces {
+		validators = append(validators, ns.validators...)
+	}
+	var invalid []TypeGuess
+	for k, v := range changes {
+		if v == nil {
+			continue
+		}
+		if _, g := c.typedValue(k, *v); g.Invalid {
+			invalid = append(invalid, g)
+		}
+	}
+	c.mu.RUnlock()
+
+	var problems []Problem
+	sort.Strings(required)
+	for _, name := range required {
+		if _, ok := view.lookupRaw(name); !ok {
+			problems = append(problems, Problem{Check: `required`, Key: name, Err: ErrKeyNotFound})
+		}
+	}
+	sort.Slice(invalid, func(i, j int) bool { return invalid[i].Key < invalid[j].Key })
+	for _, g := range invalid {
+		problems = append(problems, Problem{Check: `type`, Key: g.Key, Err: c.invalidType(g)})
+	}
+	for _, fn := range validators {
+		if e := fn(view); e != nil {
+			problems = append(problems, Problem{Check: `validator`, Err: view.scrub(e)})
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
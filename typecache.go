@@ -0,0 +1,111 @@
+package config
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+)
+
+// structField is the decoding metadata of one struct field
+type structField struct {
+	index  int
+	name   string
+	inline bool // untagged embedded struct, filled from the same properties
+}
+
+type typeCacheEntry struct {
+	t      reflect.Type
+	fields []structField
+}
+
+type typeCacheState struct {
+	mu     sync.Mutex
+	size   int
+	items  map[reflect.Type]*list.Element
+	order  *list.List
+	hits   uint64
+	misses uint64
+}
+
+// typeCache keeps the fields of recently decoded struct types, least
+// recently used first out
+var typeCache = &typeCacheState{
+	size:  256,
+	items: make(map[reflect.Type]*list.Element),
+	order: list.New(),
+}
+
+// Set how many struct types keep their field metadata cached for
+// MapToStructNested and Fetch. Default 256, 0 disables the cache.
+func SetTypeCacheSize(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	typeCache.mu.Lock()
+	defer typeCache.mu.Unlock()
+
+	typeCache.size = n
+	for typeCache.order.Len() > n {
+		typeCache.evictOldest()
+	}
+}
+
+// structFields returns the decodable fields of struct type t
+func structFields(t reflect.Type) []structField {
+	typeCache.mu.Lock()
+	if el, ok := typeCache.items[t]; ok {
+		typeCache.order.MoveToFront(el)
+		typeCache.hits++
+		fields := el.Value.(*typeCacheEntry).fields
+		typeCache.mu.Unlock()
+		return fields
+	}
+	typeCache.misses++
+	typeCache.mu.Unlock()
+
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != `` && !field.Anonymous {
+			continue
+		}
+
+		name, tagged := fieldName(field)
+		if name == `-` {
+			continue
+		}
+
+		inline := field.Anonymous && !tagged && indirectType(field.Type).Kind() == reflect.Struct
+		fields = append(fields, structField{index: i, name: name, inline: inline})
+	}
+
+	typeCache.mu.Lock()
+	defer typeCache.mu.Unlock()
+
+	if _, ok := typeCache.items[t]; !ok && typeCache.size > 0 {
+		for typeCache.order.Len() >= typeCache.size {
+			typeCache.evictOldest()
+		}
+		typeCache.items[t] = typeCache.order.PushFront(&typeCacheEntry{t: t, fields: fields})
+	}
+	return fields
+}
+
+// evictOldest drops the least recently used type. Caller must hold tc.mu.
+func (tc *typeCacheState) evictOldest() {
+	el := tc.order.Back()
+	if el == nil {
+		return
+	}
+	tc.order.Remove(el)
+	delete(tc.items, el.Value.(*typeCacheEntry).t)
+}
+
+// stats returns the cache hits and misses
+func (tc *typeCacheState) stats() (uint64, uint64) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	return tc.hits, tc.misses
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// VariantControl is the variant returned for buckets a property does not
+// define
+const VariantControl = `control`
+
+// Return the variants of property name keyed by bucket. A variant property
+// is either a JSON object value, as in
+//
+//	timeout = {"control": "1s", "treatment": "250ms"}
+//
+// or a section of plain values (timeout.control, timeout.treatment). Returns
+// nil if property is not exists or has no variants.
+func (c *Config) Variants(name string) map[string]string {
+	if val, ok := c.lookup(name); ok {
+		return parseVariants(val)
+	}
+
+	var variants map[string]string
+	for bucket := range c.GetStringMap(name) {
+		if strings.Contains(bucket, ".") {
+			continue
+		}
+		if val, ok := c.lookup(name + "." + bucket); ok {
+			if variants == nil {
+				variants = make(map[string]string)
+			}
+			variants[bucket] = val
+		}
+	}
+	return variants
+}
+
+// Read the variant bucket of property name, e.g. Variant("timeout",
+// "treatment"). Buckets the property does not define get the control
+// variant, and a property without variants returns its value to every
+// bucket. If property is not exists will return empty string.
+func (c *Config) Variant(name string, bucket string) string {
+	variants := c.Variants(name)
+	if variants == nil {
+		return c.GetString(name)
+	}
+	if val, ok := variants[bucket]; ok {
+		return val
+	}
+	return variants[VariantControl]
+}
+
+// Assign unit (e.g. a user ID) to a variant of property name and return the
+// bucket and its value. The same unit always gets the same bucket while the
+// buckets stay the same; units are spread evenly over them.
+func (c *Config) VariantFor(name string, unit string) (string, string) {
+	variants := c.Variants(name)
+	if len(variants) == 0 {
+		return ``, c.GetString(name)
+	}
+
+	buckets := make([]string, 0, len(variants))
+	for bucket := range variants {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+
+	h := fnv.New32a()
+	h.Write([]byte(normalizeKey(name) + "\x00" + unit))
+	bucket := buckets[h.Sum32()%uint32(len(buckets))]
+	return bucket, variants[bucket]
+}
+
+// parseVariants reads a JSON object of scalar values, nil for anything else
+func parseVariants(val string) map[string]string {
+	val = strings.TrimSpace(val)
+	if !strings.HasPrefix(val, "{") || !strings.HasSuffix(val, "}") {
+		return nil
+	}
+
+	var obj map[string]interface{}
+	if e := json.Unmarshal([]byte(val), &obj); e != nil || len(obj) == 0 {
+		return nil
+	}
+	variants := make(map[string]string, len(obj))
+	for bucket, v := range obj {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil
+		}
+		variants[bucket] = scalarString(v)
+	}
+	return variants
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultSource reads secrets from HashiCorp Vault. Every mounted path is read
+// with one request and its fields become properties under the mount prefix,
+// e.g. field password of secret/data/myapp mounted at vault. is
+// vault.password. KV version 2 paths (containing /data/) are unwrapped. All
+// properties are marked secret.
+type VaultSource struct {
+	Address string // e.g. https://vault:8200, VAULT_ADDR by default
+	Token   string // VAULT_TOKEN by default, unused with AppRole
+	Client  *http.Client
+
+	// RefreshInterval re-reads secrets without lease, e.g. KV version 2.
+	// Zero reads them only on Reload.
+	RefreshInterval time.Duration
+
+	mounts   []vaultMount
+	roleID   string
+	secretID string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	lease       time.Duration
+}
+
+type vaultMount struct {
+	path   string
+	prefix string
+}
+
+// Create source for the Vault server at address
+func NewVaultSource(address string) *VaultSource {
+	if address == `` {
+		address = os.Getenv(`VAULT_ADDR`)
+	}
+	return &VaultSource{
+		Address: strings.TrimRight(address, "/"),
+		Token:   os.Getenv(`VAULT_TOKEN`),
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Read the secret at path (e.g. secret/data/myapp) into properties under
+// prefix (e.g. vault.). A blank prefix puts the fields at the top level.
+func (s *VaultSource) Mount(path string, prefix string) *VaultSource {
+	s.mounts = append(s.mounts, vaultMount{path: strings.Trim(path, "/"), prefix: prefix})
+	return s
+}
+
+// Log in with AppRole instead of a token. The login is repeated when the
+// token expires.
+func (s *VaultSource) AppRole(roleID string, secretID string) *VaultSource {
+	s.roleID, s.secretID = roleID, secretID
+	return s
+}
+
+// IsSecret reports that every property of the source is secret
+func (s *VaultSource) IsSecret(name string) bool {
+	return true
+}
+
+// Load reads every mounted path
+func (s *VaultSource) Load(ctx context.Context) (map[string]string, error) {
+	token, e := s.login(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	props := make(map[string]string)
+	var lease time.Duration
+	for _, m := range s.mounts {
+		var resp struct {
+			LeaseDuration int                    `json:"lease_duration"`
+			Data          map[string]interface{} `json:"data"`
+		}
+		if e := s.call(ctx, http.MethodGet, m.path, token, nil, &resp); e != nil {
+			return nil, e
+		}
+
+		data := resp.Data
+		if inner, ok := data[`data`].(map[string]interface{}); ok && strings.Contains(m.path, `/data/`) {
+			data = inner // KV version 2
+		}
+		for k, v := range Flatten(data) {
+			props[joinKey(strings.TrimSuffix(normalizeKey(m.prefix), "."), k)] = v
+		}
+
+		d := time.Duration(resp.LeaseDuration) * time.Second
+		if d > 0 && (lease == 0 || d < lease) {
+			lease = d
+		}
+	}
+
+	s.mu.Lock()
+	s.lease = lease
+	s.mu.Unlock()
+	return props, nil
+}
+
+// Watch calls notify when the shortest secret lease or the AppRole token
+// expires, or every RefreshInterval when no secret has a lease
+func (s *VaultSource) Watch(ctx context.Context, notify func()) error {
+	for {
+		s.mu.Lock()
+		wait := s.lease
+		if !s.tokenExpiry.IsZero() {
+			if d := time.Until(s.tokenExpiry); wait == 0 || d < wait {
+				wait = d
+			}
+		}
+		s.mu.Unlock()
+
+		if wait <= 0 {
+			wait = s.RefreshInterval
+		}
+		if wait <= 0 {
+			return nil
+		}
+		if wait < time.Second {
+			wait = time.Second
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+			notify()
+		}
+	}
+}
+
+// login returns the token, logging in with AppRole when there is no valid one
+func (s *VaultSource) login(ctx context.Context) (string, error) {
+	if s.roleID == `` {
+		if s.Token == `` {
+			return ``, errors.New(`vault: no token`)
+		}
+		return s.Token, nil
+	}
+
+	s.mu.Lock()
+	token, expiry := s.token, s.tokenExpiry
+	s.mu.Unlock()
+	if token != `` && time.Until(expiry) > 10*time.Second {
+		return token, nil
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	login := map[string]string{"role_id": s.roleID, "secret_id": s.secretID}
+	if e := s.call(ctx, http.MethodPost, `auth/approle/login`, ``, login, &resp); e != nil {
+		return ``, e
+	}
+	if resp.Auth.ClientToken == `` {
+		return ``, errors.New(`vault: approle login returned no token`)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = resp.Auth.ClientToken
+	s.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	if resp.Auth.LeaseDuration == 0 {
+		s.tokenExpiry = time.Time{} // never expires
+	}
+	return s.token, nil
+}
+
+func (s *VaultSource) call(ctx context.Context, method string, path string, token string, req interface{}, resp interface{}) error {
+	var body io.Reader
+	if req != nil {
+		data, e := json.Marshal(req)
+		if e != nil {
+			return e
+		}
+		body = bytes.NewReader(data)
+	}
+
+	hr, e := http.NewRequestWithContext(ctx, method, s.Address+"/v1/"+path, body)
+	if e != nil {
+		return e
+	}
+	if token != `` {
+		hr.Header.Set("X-Vault-Token", token)
+	}
+
+	r, e := s.Client.Do(hr)
+	if e != nil {
+		return e
+	}
+	defer r.Body.Close()
+
+	data, e := io.ReadAll(r.Body)
+	if e != nil {
+		return e
+	}
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf(`vault %s: %s: %s`, path, r.Status, bytes.TrimSpace(data))
+	}
+	return json.Unmarshal(data, resp)
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// All watching Config instances share a single polling goroutine, so the
+// number of instances does not multiply goroutines or OS watch handles.
+var sharedWatcher = &fileWatcher{
+	interval: time.Second,
+	clock:    systemClock{},
+	files:    make(map[string]*watchedFile),
+}
+
+type fileWatcher struct {
+	mu       sync.Mutex
+	interval time.Duration
+	clock    Clock
+	files    map[string]*watchedFile
+	stop     chan struct{}
+}
+
+type watchedFile struct {
+	fsys    FS
+	modTime time.Time
+	size    int64
+	exists  bool
+	target  string // file the path resolves to through symlinks
+	owners  map[*Config]bool
+}
+
+// Set how often watched config files are checked for changes. Default 1s.
+func SetWatchInterval(d time.Duration) {
+	sharedWatcher.mu.Lock()
+	defer sharedWatcher.mu.Unlock()
+
+	if d > 0 {
+		sharedWatcher.interval = d
+	}
+}
+
+// Set the clock timing the polls of watched config files, so tests can
+// trigger them deterministically. nil restores the system clock.
+func SetWatchClock(clk Clock) {
+	sharedWatcher.mu.Lock()
+	defer sharedWatcher.mu.Unlock()
+
+	if clk == nil {
+		clk = systemClock{}
+	}
+	sharedWatcher.clock = clk
+}
+
+// Watch config files (including included files) and Reload when any of
+// them changes. Files replaced through a symlink, like Kubernetes ConfigMap
+// and Secret volumes, are detected too. Reload errors are logged and the
+// current properties kept.
+func (c *Config) Watch() error {
+	if c.parent != nil {
+		return c.parent.Watch()
+	}
+
+	if e := c.checkOpen(); e != nil {
+		return e
+	}
+
+	c.mu.Lock()
+	c.watching = true
+	files := c.watchPaths()
+	fsys := c.filesystem()
+	c.mu.Unlock()
+
+	sharedWatcher.set(c, fsys, files)
+	return nil
+}
+
+// Stop watching config files, remote URLs and sources. The properties stay
+// readable, but the Config can not be opened, reloaded or watched again.
+func (c *Config) Close() error {
+	if c.parent != nil {
+		return ErrSubView
+	}
+
+	c.mu.Lock()
+	c.closed = true
+	c.watching = false
+	c.stopRemotes()
+	c.stopProviders()
+	c.mu.Unlock()
+
+	sharedWatcher.remove(c)
+	return nil
+}
+
+// rewatch refreshes the watched files after a reload changed the file list
+func (c *Config) rewatch() {
+	c.mu.RLock()
+	watching := c.watching
+	files := c.watchPaths()
+	fsys := c.filesystem()
+	c.mu.RUnlock()
+
+	if watching {
+		sharedWatcher.set(c, fsys, files)
+	}
+}
+
+// set makes c the owner of exactly files, checked through fsys. A file
+// watched by several configs is checked through the FS of the first one.
+func (w *fileWatcher) set(c *Config, fsys FS, files []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.removeLocked(c)
+	for _, path := range files {
+		f, ok := w.files[path]
+		if !ok {
+			f = &watchedFile{fsys: fsys, owners: make(map[*Config]bool)}
+			f.stat(path)
+			w.files[path] = f
+		}
+		f.owners[c] = true
+	}
+
+	if len(w.files) > 0 && w.stop == nil {
+		w.stop = make(chan struct{})
+		go w.run(w.stop)
+	}
+}
+
+func (w *fileWatcher) remove(c *Config) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.removeLocked(c)
+	if len(w.files) == 0 && w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+func (w *fileWatcher) removeLocked(c *Config) {
+	for path, f := range w.files {
+		delete(f.owners, c)
+		if len(f.owners) == 0 {
+			delete(w.files, path)
+		}
+	}
+}
+
+func (w *fileWatcher) run(stop chan struct{}) {
+	for {
+		w.mu.Lock()
+		interval, clock := w.interval, w.clock
+		w.mu.Unlock()
+
+		select {
+		case <-stop:
+			return
+		case <-clock.After(interval):
+		}
+
+		for _, c := range w.poll() {
+			if e := c.Reload(); e != nil {
+				fmt.Println(`Reload config failed:`, e)
+			}
+		}
+	}
+}
+
+// poll returns the owners of files changed since the last poll
+func (w *fileWatcher) poll() []*Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := make(map[*Config]bool)
+	for path, f := range w.files {
+		if f.stat(path) {
+			for c := range f.owners {
+				changed[c] = true
+			}
+		}
+	}
+
+	owners := make([]*Config, 0, len(changed))
+	for c := range changed {
+		owners = append(owners, c)
+	}
+	return owners
+}
+
+// stat refreshes the file state and reports whether it changed
+func (f *watchedFile) stat(path string) bool {
+	st, e := f.fsys.Stat(path)
+	exists := e == nil
+
+	var modTime time.Time
+	var size int64
+	if exists {
+		modTime, size = st.ModTime(), st.Size()
+	}
+
+	target := symlinkTarget(f.fsys, path)
+
+	changed := exists != f.exists || !modTime.Equal(f.modTime) || size != f.size || target != f.target
+	f.modTime, f.size, f.exists, f.target = modTime, size, exists, target
+	return changed
+}
+
+// symlinkTarget returns the file path resolves to on the OS filesystem.
+// Kubernetes updates ConfigMap and Secret volumes by swapping the ..data
+// symlink of the mount, so a mounted file changes without being written.
+func symlinkTarget(fsys FS, path string) string {
+	if _, ok := fsys.(OSFS); !ok {
+		return ``
+	}
+	target, e := filepath.EvalSymlinks(path)
+	if e != nil {
+		return ``
+	}
+	return target
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// WatchdogOptions configures StartWatchdog
+type WatchdogOptions struct {
+	// MaxAge alerts when a polled remote URL or source was not read
+	// successfully for longer, or any source kept failing for longer. Zero
+	// disables the check.
+	MaxAge time.Duration
+	// MaxDrift alerts when a config file on disk differs from the file
+	// loaded for longer, e.g. because Watch stopped working. Zero disables
+	// the check.
+	MaxDrift time.Duration
+	// Interval between checks, 10s by default
+	Interval time.Duration
+	// OnAlert is called when a source becomes stale, once until it is
+	// fresh again. Alerts are logged when it is nil.
+	OnAlert func(a StaleAlert)
+}
+
+// StaleAlert reports a source whose properties may be out of date
+type StaleAlert struct {
+	Source string
+	Reason string    // "age" or "drift"
+	Since  time.Time // last successful read, or when the file changed on disk
+	Err    error     // last error reading the source, if any
+}
+
+func (a StaleAlert) String() string {
+	msg := fmt.Sprintf(`%s: %s since %s`, a.Source, a.Reason, a.Since.Format(time.RFC3339))
+	if a.Err != nil {
+		msg += `: ` + a.Err.Error()
+	}
+	return msg
+}
+
+// Check the sources in the background and alert when one is stale: a
+// source not refreshed within MaxAge, or a file changed on disk but not
+// reloaded within MaxDrift. Stops on Close or when stop is called.
+func (c *Config) StartWatchdog(opts WatchdogOptions) (stop func()) {
+	if c.parent != nil {
+		return c.parent.StartWatchdog(opts)
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.OnAlert == nil {
+		opts.OnAlert = func(a StaleAlert) { fmt.Println(`Config source stale:`, a) }
+	}
+
+	c.mu.Lock()
+	c.watchdogs++
+	clock := c.clock
+	c.mu.Unlock()
+	if clock == nil {
+		clock = systemClock{}
+	}
+	c.stampFiles()
+
+	done := make(chan struct{})
+	go func() {
+		alerted := make(map[string]bool)
+		drift := make(map[string]time.Time)
+		for {
+			select {
+			case <-done:
+				return
+			case <-clock.After(opts.Interval):
+			}
+			if c.State() == StateClosed {
+				return
+			}
+			c.checkStale(opts, alerted, drift)
+		}
+	}()
+
+	stopped := false
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if !stopped {
+			stopped = true
+			c.watchdogs--
+			close(done)
+		}
+	}
+}
+
+// checkStale alerts for the stale sources not alerted yet. drift holds when
+// a file was first seen changed.
+func (c *Config) checkStale(opts WatchdogOptions, alerted map[string]bool, drift map[string]time.Time) {
+	c.mu.RLock()
+	now := c.now()
+	stamps := c.fileStamps
+	c.mu.RUnlock()
+
+	stale := make(map[string]StaleAlert)
+	if opts.MaxAge > 0 {
+		for _, st := range c.SourceStatus() {
+			polled := (st.Kind == `remote` || st.Kind == `source`) && st.Interval > 0
+			if (polled || st.LastError != nil) && now.Sub(st.LastLoad) > opts.MaxAge {
+				stale[st.Name+` age`] = StaleAlert{Source: st.Name, Reason: `age`, Since: st.LastLoad, Err: st.LastError}
+			}
+		}
+	}
+
+	if opts.MaxDrift > 0 {
+		for path, loaded := range stamps {
+			current := *loaded
+			if !current.stat(path) {
+				delete(drift, path)
+				continue
+			}
+			since, ok := drift[path]
+			if !ok {
+				since = now
+				drift[path] = now
+			}
+			if now.Sub(since) > opts.MaxDrift {
+				stale[path+` drift`] = StaleAlert{Source: path, Reason: `drift`, Since: since}
+			}
+		}
+	}
+
+	for key := range alerted {
+		if _, ok := stale[key]; !ok {
+			delete(alerted, key)
+		}
+	}
+	for key, a := range stale {
+		if !alerted[key] {
+			alerted[key] = true
+			opts.OnAlert(a)
+		}
+	}
+}
+
+// restampFiles calls stampFiles when a watchdog is running
+func (c *Config) restampFiles() {
+	c.mu.RLock()
+	running := c.watchdogs > 0
+	c.mu.RUnlock()
+
+	if running {
+		c.stampFiles()
+	}
+}
+
+// stampFiles records the state of the files just loaded, compared by the
+// watchdog with the files on disk
+func (c *Config) stampFiles() {
+	c.mu.RLock()
+	paths := c.watchPaths()
+	fsys := c.filesystem()
+	c.mu.RUnlock()
+
+	stamps := make(map[string]*watchedFile, len(paths))
+	for _, path := range paths {
+		f := &watchedFile{fsys: fsys}
+		f.stat(path)
+		stamps[path] = f
+	}
+
+	c.mu.Lock()
+	c.fileStamps = stamps
+	c.mu.Unlock()
+}
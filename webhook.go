@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookOptions configures a webhook added with AddWebhook
+type WebhookOptions struct {
+	// Secret signs the body with HMAC-SHA256, sent as
+	// X-Config-Signature: sha256=<hex>. No signature when blank.
+	Secret string
+	// Retries after a failed delivery, with doubling delay starting at one
+	// second. 3 when zero, negative disables retries.
+	Retries int
+	// Client used for the requests, a client with a 10s timeout by default
+	Client *http.Client
+	// Header is added to every request, e.g. Authorization
+	Header http.Header
+}
+
+type webhook struct {
+	url  string
+	opts WebhookOptions
+}
+
+// POST every change set applied by Reload or Set to url as JSON, in the
+// change feed format: secret values are masked and long values cut. Requests
+// are sent in the background; a non 2xx response or a network error is
+// retried.
+func (c *Config) AddWebhook(url string, opts WebhookOptions) {
+	if c.parent != nil {
+		c.parent.AddWebhook(url, opts)
+		return
+	}
+
+	if opts.Retries == 0 {
+		opts.Retries = 3
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.webhooks = append(c.webhooks, webhook{url: url, opts: opts})
+}
+
+// postWebhooks sends changes to every webhook in the background
+func (c *Config) postWebhooks(changes []Change) {
+	c.mu.RLock()
+	hooks := c.webhooks
+	clock := c.clock
+	c.mu.RUnlock()
+
+	if len(hooks) == 0 {
+		return
+	}
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	body, e := json.Marshal(c.changeLine(changes))
+	if e != nil {
+		fmt.Println(`Post config webhook failed:`, e)
+		return
+	}
+
+	for _, h := range hooks {
+		go h.deliver(body, clock)
+	}
+}
+
+// deliver posts body, retrying failures
+func (h webhook) deliver(body []byte, clock Clock) {
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		e := h.post(body)
+		if e == nil {
+			return
+		}
+		if attempt >= h.opts.Retries {
+			fmt.Println(`Post config webhook failed:`, h.url, e)
+			return
+		}
+
+		<-clock.After(delay)
+		delay *= 2
+	}
+}
+
+func (h webhook) post(body []byte) error {
+	req, e := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if e != nil {
+		return e
+	}
+	for k, v := range h.opts.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set(`Content-Type`, `application/json`)
+	if h.opts.Secret != `` {
+		mac := hmac.New(sha256.New, []byte(h.opts.Secret))
+		mac.Write(body)
+		req.Header.Set(`X-Config-Signature`, `sha256=`+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, e := h.opts.Client.Do(req)
+	if e != nil {
+		return e
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf(`%s: %s`, h.url, resp.Status)
+	}
+	return nil
+}